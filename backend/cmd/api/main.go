@@ -23,6 +23,18 @@ func main() {
 	database.InitR2()
 	services.InitGemini()
 
+	go services.RunDataExportCleanup(time.Hour)
+	go services.RunSearchLexiconRefresh(30 * time.Minute)
+	go services.RunSavedSearchDigest(time.Hour)
+	go services.RunListingExpiry(time.Hour)
+	go services.RunListingPurge(6 * time.Hour)
+	go services.RunListingStatsRollup(15 * time.Minute)
+	go services.RunListingImportCleanup(time.Hour)
+	go services.RunListingImageBackfill(10 * time.Minute)
+	go services.RunUploadCleanup(15 * time.Minute)
+	go services.RunStorageGC(24 * time.Hour)
+	go services.RunStorageOutbox(time.Minute)
+
 	router := gin.Default()
 
 	router.MaxMultipartMemory = 8 << 20
@@ -52,27 +64,76 @@ func main() {
 		user.DELETE("", handlers.DeleteUser)
 		user.PATCH("/avatar", handlers.UploadAvatar)
 		user.GET("/dashboard", handlers.GetDashboard)
+		user.POST("/export", handlers.RequestDataExport)
+		user.GET("/export", handlers.GetDataExports)
+		user.GET("/export/:id", handlers.GetDataExport)
+		user.POST("/uploads", handlers.CreateUpload)
+		user.POST("/uploads/:id/confirm", handlers.ConfirmUpload)
 
 		{
 			// user's listing CRUD
 			listing := user.Group("/listings")
 			listing.GET("", handlers.GetMyListings)
+			listing.GET("/export", handlers.ExportMyListings)
 			listing.POST("", handlers.CreateListing)
+			listing.POST("/import", handlers.ImportListings)
+			listing.GET("/imports", handlers.GetListingImports)
+			listing.GET("/imports/:id", handlers.GetListingImport)
 			listing.PATCH("/:id", handlers.UpdateListing)
 			listing.DELETE("/:id", handlers.DeleteListing)
+			listing.GET("/:id/history", handlers.GetListingStatusHistory)
+			listing.POST("/:id/renew", handlers.RenewListing)
+			listing.POST("/:id/clone", handlers.CloneListing)
+			listing.GET("/:id/images", handlers.GetListingImages)
+			listing.PUT("/:id/images", handlers.UpdateListingImages)
+			listing.POST("/:id/template", handlers.SaveListingAsTemplate)
+			listing.GET("/trash", handlers.GetListingTrash)
+			listing.POST("/:id/restore", handlers.RestoreListing)
+			listing.GET("/:id/revisions", handlers.GetListingRevisions)
+			listing.GET("/:id/revisions/diff", handlers.GetListingRevisionDiff)
+			listing.GET("/:id/analytics", handlers.GetListingAnalytics)
 			listing.POST("/wishlist/:id", handlers.ToggleWishlist)
 			listing.GET("/wishlist", handlers.GetListingsFromWishlist)
+			listing.GET("/wishlist/export", handlers.ExportWishlist)
 			listing.POST("/report/:id", handlers.CreateAIReport)
 		}
 
+		{
+			// user's listing templates
+			templates := user.Group("/templates")
+			templates.GET("", handlers.GetListingTemplates)
+			templates.POST("", handlers.CreateListingTemplate)
+			templates.PATCH("/:id", handlers.RenameListingTemplate)
+			templates.DELETE("/:id", handlers.DeleteListingTemplate)
+			templates.POST("/:id/listings", handlers.CreateListingFromTemplate)
+		}
+
 		{
 			// user's ratings
 			ratings := user.Group("/ratings")
 			ratings.POST("", handlers.CreateRating)
 			ratings.GET("/given", handlers.GetMyRatingsGiven)
+			ratings.GET("/given/export", handlers.ExportMyRatingsGiven)
 			ratings.PATCH("/:id", handlers.UpdateRating)
 			ratings.DELETE("/:id", handlers.DeleteRating)
 		}
+
+		{
+			// user's saved searches
+			searches := user.Group("/saved-searches")
+			searches.GET("", handlers.GetSavedSearches)
+			searches.POST("", handlers.CreateSavedSearch)
+			searches.PATCH("/:id", handlers.UpdateSavedSearch)
+			searches.DELETE("/:id", handlers.DeleteSavedSearch)
+		}
+
+		{
+			// user's notifications
+			notifications := user.Group("/notifications")
+			notifications.GET("", handlers.GetNotifications)
+			notifications.PATCH("/:id/read", handlers.MarkNotificationRead)
+			notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+		}
 	}
 
 	{
@@ -81,11 +142,18 @@ func main() {
 		{
 			listings := public.Group("/listings")
 			listings.GET("/search", middleware.OptionalAuth(), handlers.Search)
+			listings.GET("/suggest", handlers.Suggest)
 			listings.GET("", middleware.OptionalAuth(), handlers.GetListings)
 			listings.GET("/:id", middleware.OptionalAuth(), handlers.GetListing)
+			listings.POST("/:id/contact", middleware.OptionalAuth(), handlers.RecordContactClick)
 
 		}
 
+		public.GET("/categories", handlers.GetCategories)
+		public.GET("/categories/:slug/attributes", handlers.GetCategoryAttributes)
+		public.GET("/campus-locations", handlers.GetCampusLocations)
+		public.GET("/currencies", handlers.GetCurrencies)
+
 		{
 			user := public.Group("/users")
 			user.GET("/:id", middleware.OptionalAuth(), handlers.GetUserWithListing)
@@ -114,6 +182,32 @@ func main() {
 		admin.GET("/listings", handlers.GetAllListings)
 		admin.DELETE("/users/:id", handlers.AdminDeleteUser)
 		admin.DELETE("/listings/:id", handlers.AdminDeleteListing)
+		admin.PATCH("/listings/:id/status", handlers.AdminUpdateListingStatus)
+		admin.POST("/listings/:id/restore", handlers.AdminRestoreListing)
+		admin.GET("/listings/:id/revisions", handlers.AdminGetListingRevisions)
+		admin.GET("/listings/:id/revisions/diff", handlers.AdminGetListingRevisionDiff)
+		admin.POST("/listings/:id/revisions/:revision/revert", handlers.AdminRevertListing)
+		admin.GET("/categories", handlers.AdminGetCategories)
+		admin.POST("/categories", handlers.AdminCreateCategory)
+		admin.PATCH("/categories/:id", handlers.AdminUpdateCategory)
+		admin.DELETE("/categories/:id", handlers.AdminDeleteCategory)
+		admin.GET("/categories/:id/attributes", handlers.AdminGetCategoryAttributes)
+		admin.POST("/categories/:id/attributes", handlers.AdminCreateCategoryAttribute)
+		admin.PATCH("/categories/:id/attributes/:attributeId", handlers.AdminUpdateCategoryAttribute)
+		admin.DELETE("/categories/:id/attributes/:attributeId", handlers.AdminDeleteCategoryAttribute)
+		admin.GET("/campus-locations", handlers.AdminGetCampusLocations)
+		admin.POST("/campus-locations", handlers.AdminCreateCampusLocation)
+		admin.PATCH("/campus-locations/:id", handlers.AdminUpdateCampusLocation)
+		admin.DELETE("/campus-locations/:id", handlers.AdminDeleteCampusLocation)
+		admin.PUT("/exchange-rates/:currency", handlers.AdminUpdateExchangeRate)
+		admin.DELETE("/exchange-rates/:currency", handlers.AdminDeleteExchangeRate)
+		admin.POST("/exchange-rates/import", handlers.AdminImportExchangeRates)
+		admin.GET("/storage-gc/runs", handlers.AdminGetStorageGCRuns)
+		admin.POST("/storage-gc/runs", handlers.AdminStartStorageGC)
+		admin.GET("/storage-gc/runs/:id", handlers.AdminGetStorageGCRun)
+		admin.GET("/storage-outbox", handlers.AdminGetStorageOutbox)
+		admin.POST("/storage-outbox/:id/retry", handlers.AdminRetryStorageOutboxEntry)
+		admin.DELETE("/storage-outbox/:id", handlers.AdminDeleteStorageOutboxEntry)
 	}
 
 	router.Run(":8080")
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_ratings_rater_id_created_at;
+DROP INDEX IF EXISTS idx_ratings_user_id_created_at;
+DROP INDEX IF EXISTS idx_wishlist_listings_user_id_created_at;
+DROP INDEX IF EXISTS idx_listings_user_id_created_at;
+DROP INDEX IF EXISTS idx_listings_wishlist_count_id;
+DROP INDEX IF EXISTS idx_listings_price_id;
+DROP INDEX IF EXISTS idx_listings_created_at_id;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS wishlist_count;
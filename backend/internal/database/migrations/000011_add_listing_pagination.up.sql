@@ -0,0 +1,20 @@
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS wishlist_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE listings
+SET wishlist_count = counts.total
+FROM (
+    SELECT listing_id, COUNT(*) AS total
+    FROM wishlist_listings
+    GROUP BY listing_id
+) AS counts
+WHERE counts.listing_id = listings.id;
+
+-- keyset pagination indexes, one per sort order
+CREATE INDEX idx_listings_created_at_id ON listings(created_at DESC, id DESC);
+CREATE INDEX idx_listings_price_id ON listings(price, id);
+CREATE INDEX idx_listings_wishlist_count_id ON listings(wishlist_count DESC, id DESC);
+CREATE INDEX idx_listings_user_id_created_at ON listings(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_wishlist_listings_user_id_created_at ON wishlist_listings(user_id, created_at DESC, listing_id DESC);
+CREATE INDEX idx_ratings_user_id_created_at ON ratings(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_ratings_rater_id_created_at ON ratings(rater_id, created_at DESC, id DESC);
+CREATE INDEX idx_users_created_at_id ON users(created_at DESC, id DESC);
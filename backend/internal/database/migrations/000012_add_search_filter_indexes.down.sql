@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_average_rating;
+DROP INDEX IF EXISTS idx_users_university_lower;
+DROP INDEX IF EXISTS idx_listings_open_price;
+DROP INDEX IF EXISTS idx_listings_open_created_at;
+DROP INDEX IF EXISTS idx_listings_category_lower;
@@ -0,0 +1,5 @@
+CREATE INDEX idx_listings_category_lower ON listings(LOWER(category));
+CREATE INDEX idx_listings_open_created_at ON listings(created_at DESC, id DESC) WHERE is_closed = FALSE;
+CREATE INDEX idx_listings_open_price ON listings(price, id) WHERE is_closed = FALSE;
+CREATE INDEX idx_users_university_lower ON users(LOWER(university));
+CREATE INDEX idx_users_average_rating ON users(average_rating);
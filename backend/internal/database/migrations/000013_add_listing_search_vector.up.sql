@@ -0,0 +1,13 @@
+-- English and Russian get stemmed lexemes; the simple configuration keeps every
+-- word unstemmed, which covers Kazakh (no stemmer ships with Postgres) and
+-- brand names or model numbers the stemmers would mangle.
+ALTER TABLE listings ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_listings_search_vector ON listings USING GIN(search_vector);
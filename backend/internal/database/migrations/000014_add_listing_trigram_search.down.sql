@@ -0,0 +1,4 @@
+DROP MATERIALIZED VIEW IF EXISTS listing_title_words;
+DROP INDEX IF EXISTS idx_listings_category_trgm;
+DROP INDEX IF EXISTS idx_listings_title_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
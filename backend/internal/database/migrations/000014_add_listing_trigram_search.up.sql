@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_listings_title_trgm ON listings USING GIN (LOWER(title) gin_trgm_ops);
+CREATE INDEX idx_listings_category_trgm ON listings USING GIN (LOWER(category) gin_trgm_ops);
+
+-- distinct title words used for "did you mean" corrections, refreshed by a background job
+CREATE MATERIALIZED VIEW listing_title_words AS
+SELECT word, ndoc
+FROM ts_stat('SELECT to_tsvector(''simple'', title) FROM listings');
+
+CREATE UNIQUE INDEX idx_listing_title_words_word ON listing_title_words(word);
+CREATE INDEX idx_listing_title_words_trgm ON listing_title_words USING GIN (word gin_trgm_ops);
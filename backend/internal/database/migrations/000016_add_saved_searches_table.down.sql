@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_saved_search_matches_pending;
+DROP TABLE IF EXISTS saved_search_matches;
+DROP INDEX IF EXISTS idx_saved_searches_category;
+DROP INDEX IF EXISTS idx_saved_searches_user_id;
+DROP TABLE IF EXISTS saved_searches;
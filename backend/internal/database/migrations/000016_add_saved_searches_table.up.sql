@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    frequency TEXT NOT NULL DEFAULT 'instant',
+    last_digest_at TIMESTAMP,
+
+    CONSTRAINT saved_searches_frequency_check
+        CHECK (frequency IN ('instant', 'daily'))
+);
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+CREATE INDEX idx_saved_searches_category ON saved_searches(LOWER(params->>'category'));
+
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    saved_search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    notified_at TIMESTAMP,
+
+    CONSTRAINT unique_saved_search_listing
+        UNIQUE (saved_search_id, listing_id)
+);
+
+CREATE INDEX idx_saved_search_matches_pending ON saved_search_matches(saved_search_id) WHERE notified_at IS NULL;
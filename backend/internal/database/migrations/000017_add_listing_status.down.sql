@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS listing_status_transitions;
+
+DROP INDEX IF EXISTS idx_listings_visible_price;
+DROP INDEX IF EXISTS idx_listings_visible_created_at;
+DROP INDEX IF EXISTS idx_listings_user_status;
+
+ALTER TABLE listings ADD COLUMN is_closed BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE listings SET is_closed = TRUE WHERE status NOT IN ('active', 'reserved', 'draft');
+
+ALTER TABLE listings
+    DROP CONSTRAINT IF EXISTS listings_status_check,
+    DROP COLUMN removed_at,
+    DROP COLUMN archived_at,
+    DROP COLUMN expired_at,
+    DROP COLUMN sold_at,
+    DROP COLUMN reserved_at,
+    DROP COLUMN published_at,
+    DROP COLUMN status;
+
+CREATE INDEX idx_listings_open_created_at ON listings(created_at DESC, id DESC) WHERE is_closed = FALSE;
+CREATE INDEX idx_listings_open_price ON listings(price, id) WHERE is_closed = FALSE;
@@ -0,0 +1,39 @@
+ALTER TABLE listings
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active',
+    ADD COLUMN published_at TIMESTAMP,
+    ADD COLUMN reserved_at TIMESTAMP,
+    ADD COLUMN sold_at TIMESTAMP,
+    ADD COLUMN expired_at TIMESTAMP,
+    ADD COLUMN archived_at TIMESTAMP,
+    ADD COLUMN removed_at TIMESTAMP,
+    ADD CONSTRAINT listings_status_check
+        CHECK (status IN ('draft', 'active', 'reserved', 'sold', 'expired', 'archived', 'removed'));
+
+-- closed listings can't be told apart, treat them as archived by the owner
+UPDATE listings SET published_at = created_at;
+UPDATE listings SET status = 'archived', archived_at = updated_at WHERE is_closed = TRUE;
+
+DROP INDEX IF EXISTS idx_listings_open_created_at;
+DROP INDEX IF EXISTS idx_listings_open_price;
+
+ALTER TABLE listings DROP COLUMN is_closed;
+
+CREATE INDEX idx_listings_user_status ON listings(user_id, status);
+CREATE INDEX idx_listings_visible_created_at ON listings(created_at DESC, id DESC) WHERE status IN ('active', 'reserved');
+CREATE INDEX idx_listings_visible_price ON listings(price, id) WHERE status IN ('active', 'reserved');
+
+CREATE TABLE IF NOT EXISTS listing_status_transitions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+
+    CONSTRAINT listing_status_transitions_actor_check
+        CHECK (actor IN ('owner', 'admin', 'system'))
+);
+
+CREATE INDEX idx_listing_status_transitions_listing_id ON listing_status_transitions(listing_id, created_at);
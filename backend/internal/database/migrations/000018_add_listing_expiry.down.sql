@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_listings_active_expires_at;
+
+ALTER TABLE listings
+    DROP COLUMN expiry_reminded_at,
+    DROP COLUMN expires_at;
@@ -0,0 +1,8 @@
+ALTER TABLE listings
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN expiry_reminded_at TIMESTAMP;
+
+-- existing listings get the default period counted from now, so nothing expires on deploy
+UPDATE listings SET expires_at = CURRENT_TIMESTAMP + INTERVAL '90 days' WHERE status = 'active';
+
+CREATE INDEX idx_listings_active_expires_at ON listings(expires_at) WHERE status = 'active';
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_listings_pending_purge;
+DROP INDEX IF EXISTS idx_listings_deleted_at;
+
+-- soft-deleted listings would become visible again, remove them for good
+DELETE FROM listings WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE listings
+    DROP CONSTRAINT IF EXISTS listings_deleted_by_check,
+    DROP COLUMN images_purged_at,
+    DROP COLUMN deleted_by,
+    DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+ALTER TABLE listings
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by TEXT,
+    ADD COLUMN images_purged_at TIMESTAMP,
+    ADD CONSTRAINT listings_deleted_by_check
+        CHECK (deleted_by IS NULL OR deleted_by IN ('owner', 'admin'));
+
+CREATE INDEX idx_listings_deleted_at ON listings(deleted_at);
+CREATE INDEX idx_listings_pending_purge ON listings(deleted_at) WHERE deleted_at IS NOT NULL AND images_purged_at IS NULL;
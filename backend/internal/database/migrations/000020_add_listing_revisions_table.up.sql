@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS listing_revisions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    actor TEXT NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    snapshot JSONB NOT NULL,
+
+    CONSTRAINT unique_listing_revision
+        UNIQUE (listing_id, revision),
+    CONSTRAINT listing_revisions_actor_check
+        CHECK (actor IN ('owner', 'admin', 'system'))
+);
+
+-- current state of existing listings becomes their first revision
+INSERT INTO listing_revisions (created_at, listing_id, revision, actor, actor_id, snapshot)
+SELECT
+    updated_at,
+    id,
+    1,
+    'owner',
+    user_id,
+    jsonb_build_object(
+        'title', title,
+        'description', COALESCE(description, ''),
+        'price', price,
+        'category', category,
+        'image_urls', to_jsonb(COALESCE(image_urls, '{}'::text[]))
+    )
+FROM listings;
@@ -0,0 +1,13 @@
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS fk_listings_category;
+
+-- listings in categories added later fall back to the old default
+UPDATE listings SET category = 'furniture'
+WHERE category NOT IN ('furniture', 'electronics', 'books', 'clothing', 'services');
+UPDATE listings SET category = INITCAP(category);
+
+ALTER TABLE listings ALTER COLUMN category SET DEFAULT 'Furniture';
+ALTER TABLE listings
+ADD CONSTRAINT category_check
+CHECK (category IN ('Electronics', 'Furniture', 'Books', 'Clothing', 'Services'));
+
+DROP TABLE IF EXISTS categories;
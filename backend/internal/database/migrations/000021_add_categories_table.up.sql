@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+    slug TEXT NOT NULL,
+    names JSONB NOT NULL DEFAULT '{}',
+    icon TEXT NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    expiry_days INTEGER,
+
+    CONSTRAINT unique_category_slug
+        UNIQUE (slug),
+    CONSTRAINT categories_slug_check
+        CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    CONSTRAINT categories_expiry_days_check
+        CHECK (expiry_days IS NULL OR expiry_days > 0),
+    CONSTRAINT categories_parent_check
+        CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+INSERT INTO categories (slug, names, icon, sort_order, expiry_days) VALUES
+    ('furniture', '{"en": "Furniture", "ru": "Мебель"}', 'furniture', 1, 90),
+    ('electronics', '{"en": "Electronics", "ru": "Электроника"}', 'electronics', 2, 60),
+    ('books', '{"en": "Books", "ru": "Книги"}', 'books', 3, 120),
+    ('clothing', '{"en": "Clothing", "ru": "Одежда"}', 'clothing', 4, 60),
+    ('services', '{"en": "Services", "ru": "Услуги"}', 'services', 5, 30);
+
+-- listings reference categories by slug, renames cascade
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS category_check;
+ALTER TABLE listings ALTER COLUMN category DROP DEFAULT;
+
+UPDATE listings SET category = LOWER(category);
+UPDATE saved_searches
+SET params = jsonb_set(params, '{category}', to_jsonb(LOWER(params->>'category')))
+WHERE COALESCE(params->>'category', '') <> '';
+UPDATE listing_revisions
+SET snapshot = jsonb_set(snapshot, '{category}', to_jsonb(LOWER(snapshot->>'category')))
+WHERE snapshot ? 'category';
+
+ALTER TABLE listings
+ADD CONSTRAINT fk_listings_category
+FOREIGN KEY (category) REFERENCES categories(slug) ON UPDATE CASCADE ON DELETE RESTRICT;
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_listings_search_vector;
+ALTER TABLE listings DROP COLUMN search_vector;
+ALTER TABLE listings ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_listings_search_vector ON listings USING GIN(search_vector);
+
+DROP INDEX IF EXISTS idx_listings_attributes;
+ALTER TABLE listings DROP COLUMN attributes;
+
+DROP TABLE IF EXISTS category_attributes;
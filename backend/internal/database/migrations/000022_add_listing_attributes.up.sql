@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    names JSONB NOT NULL DEFAULT '{}',
+    type TEXT NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    enum_values TEXT[] NOT NULL DEFAULT '{}',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT unique_category_attribute_key
+        UNIQUE (category_id, key),
+    CONSTRAINT category_attributes_key_check
+        CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    CONSTRAINT category_attributes_type_check
+        CHECK (type IN ('text', 'number', 'boolean', 'enum', 'isbn')),
+    CONSTRAINT category_attributes_enum_check
+        CHECK (type <> 'enum' OR cardinality(enum_values) > 0)
+);
+
+CREATE INDEX idx_category_attributes_category_id ON category_attributes(category_id);
+
+INSERT INTO category_attributes (category_id, key, names, type, required, enum_values, sort_order)
+SELECT categories.id, attrs.key, attrs.names::jsonb, attrs.type, attrs.required, attrs.enum_values::text[], attrs.sort_order
+FROM (VALUES
+    ('books', 'isbn', '{"en": "ISBN", "ru": "ISBN"}', 'isbn', FALSE, '{}', 1),
+    ('books', 'author', '{"en": "Author", "ru": "Автор"}', 'text', FALSE, '{}', 2),
+    ('books', 'edition', '{"en": "Edition", "ru": "Издание"}', 'text', FALSE, '{}', 3),
+    ('clothing', 'size', '{"en": "Size", "ru": "Размер"}', 'enum', TRUE, '{XS,S,M,L,XL,XXL}', 1),
+    ('clothing', 'gender', '{"en": "Gender", "ru": "Пол"}', 'enum', FALSE, '{men,women,unisex}', 2),
+    ('electronics', 'brand', '{"en": "Brand", "ru": "Бренд"}', 'text', FALSE, '{}', 1),
+    ('electronics', 'model', '{"en": "Model", "ru": "Модель"}', 'text', FALSE, '{}', 2),
+    ('electronics', 'storage_gb', '{"en": "Storage (GB)", "ru": "Память (ГБ)"}', 'number', FALSE, '{}', 3)
+) AS attrs(category, key, names, type, required, enum_values, sort_order)
+JOIN categories ON categories.slug = attrs.category;
+
+ALTER TABLE listings ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_listings_attributes ON listings USING GIN (attributes jsonb_path_ops);
+
+-- attribute values such as author or ISBN are searchable as free text too
+DROP INDEX IF EXISTS idx_listings_search_vector;
+ALTER TABLE listings DROP COLUMN search_vector;
+ALTER TABLE listings ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B') ||
+    setweight(jsonb_to_tsvector('simple', attributes, '["string", "numeric"]'), 'C')
+) STORED;
+
+CREATE INDEX idx_listings_search_vector ON listings USING GIN(search_vector);
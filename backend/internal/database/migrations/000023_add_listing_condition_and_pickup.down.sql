@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_listings_pickup_location_id;
+DROP INDEX IF EXISTS idx_listings_condition;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS pickup_location_id;
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_condition_check;
+ALTER TABLE listings DROP COLUMN IF EXISTS condition;
+
+DROP TABLE IF EXISTS campus_locations;
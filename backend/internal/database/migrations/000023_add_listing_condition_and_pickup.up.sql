@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS campus_locations (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    university TEXT NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'building',
+    address TEXT NOT NULL DEFAULT '',
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+
+    CONSTRAINT unique_campus_location_name
+        UNIQUE (university, name),
+    CONSTRAINT campus_locations_kind_check
+        CHECK (kind IN ('building', 'dorm', 'other')),
+    CONSTRAINT campus_locations_coordinates_check
+        CHECK (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX idx_campus_locations_university ON campus_locations(LOWER(university));
+
+-- existing listings never stated a condition, so the column stays nullable
+ALTER TABLE listings ADD COLUMN condition TEXT;
+ALTER TABLE listings ADD CONSTRAINT listings_condition_check
+    CHECK (condition IN ('new', 'like_new', 'good', 'fair', 'for_parts'));
+
+ALTER TABLE listings ADD COLUMN pickup_location_id INTEGER REFERENCES campus_locations(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_listings_condition ON listings(condition) WHERE condition IS NOT NULL;
+CREATE INDEX idx_listings_pickup_location_id ON listings(pickup_location_id) WHERE pickup_location_id IS NOT NULL;
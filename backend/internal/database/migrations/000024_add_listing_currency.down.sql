@@ -0,0 +1,22 @@
+ALTER TABLE users DROP COLUMN IF EXISTS preferred_currency;
+
+DROP INDEX IF EXISTS idx_listings_currency;
+DROP INDEX IF EXISTS idx_listings_visible_price_base;
+DROP INDEX IF EXISTS idx_listings_price_base_id;
+
+-- prices go back to US dollars
+ALTER TABLE listings ADD COLUMN price DOUBLE PRECISION NOT NULL DEFAULT 0;
+UPDATE listings SET price = ROUND(price_base::numeric, 2);
+ALTER TABLE listings ALTER COLUMN price DROP DEFAULT;
+
+CREATE INDEX idx_listings_price_id ON listings(price, id);
+CREATE INDEX idx_listings_visible_price ON listings(price, id) WHERE status IN ('active', 'reserved');
+
+UPDATE listing_revisions SET snapshot = snapshot - 'currency';
+
+ALTER TABLE listings DROP COLUMN IF EXISTS price_base;
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_price_minor_check;
+ALTER TABLE listings DROP COLUMN IF EXISTS price_minor;
+ALTER TABLE listings DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,50 @@
+-- rate is how many units of the currency one US dollar buys
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate NUMERIC(24, 10) NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT exchange_rates_currency_check
+        CHECK (currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT exchange_rates_rate_check
+        CHECK (rate > 0),
+    CONSTRAINT exchange_rates_base_check
+        CHECK (currency <> 'USD' OR rate = 1)
+);
+
+-- placeholder rates until admins import current ones
+INSERT INTO exchange_rates (currency, rate, source) VALUES
+    ('USD', 1, 'base'),
+    ('KZT', 500, 'seed'),
+    ('RUB', 80, 'seed'),
+    ('EUR', 0.86, 'seed'),
+    ('UZS', 12000, 'seed'),
+    ('KGS', 87, 'seed');
+
+-- prices so far were entered in US dollars
+ALTER TABLE listings ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD'
+    REFERENCES exchange_rates(currency) ON UPDATE CASCADE;
+ALTER TABLE listings ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE listings ADD COLUMN price_minor BIGINT NOT NULL DEFAULT 0;
+UPDATE listings SET price_minor = ROUND(price * 100);
+ALTER TABLE listings ADD CONSTRAINT listings_price_minor_check CHECK (price_minor >= 0);
+
+-- price in US dollars, kept in sync by the app so listings in different currencies sort together
+ALTER TABLE listings ADD COLUMN price_base DOUBLE PRECISION NOT NULL DEFAULT 0;
+UPDATE listings SET price_base = price;
+
+-- earlier revisions were priced in US dollars as well
+UPDATE listing_revisions SET snapshot = snapshot || '{"currency": "USD"}'::jsonb;
+
+DROP INDEX IF EXISTS idx_listings_price_id;
+DROP INDEX IF EXISTS idx_listings_visible_price;
+ALTER TABLE listings DROP COLUMN price;
+
+CREATE INDEX idx_listings_price_base_id ON listings(price_base, id);
+CREATE INDEX idx_listings_visible_price_base ON listings(price_base, id) WHERE status IN ('active', 'reserved');
+CREATE INDEX idx_listings_currency ON listings(currency);
+
+ALTER TABLE users ADD COLUMN preferred_currency TEXT
+    REFERENCES exchange_rates(currency) ON UPDATE CASCADE ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS listing_prices (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    price_minor BIGINT NOT NULL,
+    currency TEXT NOT NULL REFERENCES exchange_rates(currency) ON UPDATE CASCADE,
+    price_base DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX idx_listing_prices_listing_id_created_at ON listing_prices(listing_id, created_at);
+
+-- history starts with the price each listing has now
+INSERT INTO listing_prices (created_at, listing_id, price_minor, currency, price_base)
+SELECT created_at, id, price_minor, currency, price_base FROM listings;
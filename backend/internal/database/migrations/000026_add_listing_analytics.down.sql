@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS listing_daily_stats;
+DROP TABLE IF EXISTS listing_events;
@@ -0,0 +1,27 @@
+-- raw events are kept briefly for deduplication and rolled up into daily stats
+CREATE TABLE IF NOT EXISTS listing_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    viewer_key TEXT NOT NULL,
+
+    CONSTRAINT listing_events_type_check
+        CHECK (type IN ('view', 'wishlist_add', 'contact_click'))
+);
+
+CREATE INDEX idx_listing_events_dedup ON listing_events(listing_id, type, viewer_key, created_at DESC);
+CREATE INDEX idx_listing_events_created_at ON listing_events(created_at);
+
+CREATE TABLE IF NOT EXISTS listing_daily_stats (
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    unique_viewers INTEGER NOT NULL DEFAULT 0,
+    wishlist_adds INTEGER NOT NULL DEFAULT 0,
+    contact_clicks INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (listing_id, day)
+);
+
+CREATE INDEX idx_listing_daily_stats_day ON listing_daily_stats(day);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS listing_import_rows;
+DROP INDEX IF EXISTS idx_listing_imports_status;
+DROP INDEX IF EXISTS idx_listing_imports_user_id;
+DROP TABLE IF EXISTS listing_imports;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS listing_imports (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    succeeded_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    completed_at TIMESTAMP,
+
+    CONSTRAINT listing_imports_status_check
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed'))
+);
+
+CREATE INDEX idx_listing_imports_user_id ON listing_imports(user_id);
+CREATE INDEX idx_listing_imports_status ON listing_imports(status);
+
+-- one result per CSV row, line is the row's line in the file
+CREATE TABLE IF NOT EXISTS listing_import_rows (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES listing_imports(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    listing_id INTEGER REFERENCES listings(id) ON DELETE SET NULL,
+    error TEXT,
+
+    UNIQUE (import_id, line)
+);
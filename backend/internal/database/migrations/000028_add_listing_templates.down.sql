@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_listing_templates_user_id;
+DROP TABLE IF EXISTS listing_templates;
@@ -0,0 +1,22 @@
+-- templates hold their own copies of images so they outlive the listing they came from
+CREATE TABLE IF NOT EXISTS listing_templates (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    image_urls TEXT[],
+    price_minor BIGINT NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL REFERENCES exchange_rates(currency) ON UPDATE CASCADE,
+    category TEXT NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    condition TEXT,
+    pickup_location_id INTEGER REFERENCES campus_locations(id) ON DELETE SET NULL,
+
+    CONSTRAINT listing_templates_condition_check
+        CHECK (condition IN ('new', 'like_new', 'good', 'fair', 'for_parts'))
+);
+
+CREATE INDEX idx_listing_templates_user_id ON listing_templates(user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_listing_images_pending_metadata;
+DROP INDEX IF EXISTS idx_listing_images_cover;
+DROP TABLE IF EXISTS listing_images;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS listing_images (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    alt_text TEXT NOT NULL DEFAULT '',
+    -- unknown for images uploaded before this table existed until the backfill reaches them
+    width INTEGER,
+    height INTEGER,
+    byte_size BIGINT,
+    content_hash TEXT,
+
+    CONSTRAINT listing_images_url_unique UNIQUE (listing_id, url),
+    -- checked at commit so images can swap positions within a transaction
+    CONSTRAINT listing_images_position_unique UNIQUE (listing_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE UNIQUE INDEX idx_listing_images_cover ON listing_images(listing_id) WHERE is_cover;
+CREATE INDEX idx_listing_images_pending_metadata ON listing_images(id) WHERE content_hash IS NULL;
+
+-- existing images keep their order, the first one is the cover
+INSERT INTO listing_images (listing_id, url, position, is_cover)
+SELECT listings.id, images.url, images.ordinality - 1, images.ordinality = 1
+FROM listings, unnest(listings.image_urls) WITH ORDINALITY AS images(url, ordinality)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_listing_images_pending_metadata;
+CREATE INDEX idx_listing_images_pending_metadata ON listing_images(id) WHERE content_hash IS NULL;
+
+ALTER TABLE listing_images DROP COLUMN IF EXISTS thumb_url;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS card_url;
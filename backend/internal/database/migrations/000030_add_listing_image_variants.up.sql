@@ -0,0 +1,6 @@
+ALTER TABLE listing_images ADD COLUMN card_url TEXT;
+ALTER TABLE listing_images ADD COLUMN thumb_url TEXT;
+
+-- images uploaded so far have no variants yet, the backfill makes them
+DROP INDEX idx_listing_images_pending_metadata;
+CREATE INDEX idx_listing_images_pending_metadata ON listing_images(id) WHERE content_hash IS NULL OR card_url IS NULL;
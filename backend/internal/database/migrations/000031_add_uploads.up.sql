@@ -0,0 +1,29 @@
+-- direct uploads to storage: the client PUTs the file to key with a presigned
+-- URL, confirming it turns it into an image that can be attached once
+CREATE TABLE IF NOT EXISTS uploads (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    byte_size BIGINT NOT NULL,
+    content_hash TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    image_url TEXT,
+    card_url TEXT,
+    thumb_url TEXT,
+    width INTEGER,
+    height INTEGER,
+    image_byte_size BIGINT,
+    image_content_hash TEXT,
+
+    CONSTRAINT uploads_purpose_check CHECK (purpose IN ('listing', 'avatar')),
+    CONSTRAINT uploads_status_check CHECK (status IN ('pending', 'confirmed'))
+);
+
+CREATE INDEX idx_uploads_user_id ON uploads(user_id);
+CREATE INDEX idx_uploads_expires_at ON uploads(expires_at);
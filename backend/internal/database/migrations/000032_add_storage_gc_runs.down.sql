@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_storage_gc_runs_created_at;
+DROP TABLE IF EXISTS storage_gc_runs;
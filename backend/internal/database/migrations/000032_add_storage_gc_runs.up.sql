@@ -0,0 +1,25 @@
+-- one row per run of the orphaned image collector, the report of what it found
+CREATE TABLE IF NOT EXISTS storage_gc_runs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status TEXT NOT NULL DEFAULT 'running',
+    dry_run BOOLEAN NOT NULL,
+    grace_period_hours INTEGER NOT NULL,
+    scanned_objects INTEGER NOT NULL DEFAULT 0,
+    scanned_bytes BIGINT NOT NULL DEFAULT 0,
+    recent_objects INTEGER NOT NULL DEFAULT 0,
+    orphaned_objects INTEGER NOT NULL DEFAULT 0,
+    orphaned_bytes BIGINT NOT NULL DEFAULT 0,
+    deleted_objects INTEGER NOT NULL DEFAULT 0,
+    deleted_bytes BIGINT NOT NULL DEFAULT 0,
+    failed_deletes INTEGER NOT NULL DEFAULT 0,
+    orphan_keys TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT,
+    finished_at TIMESTAMP,
+
+    CONSTRAINT storage_gc_runs_status_check
+        CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+CREATE INDEX idx_storage_gc_runs_created_at ON storage_gc_runs(created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_storage_outbox_status;
+DROP INDEX IF EXISTS idx_storage_outbox_due;
+DROP TABLE IF EXISTS storage_outbox;
@@ -0,0 +1,20 @@
+-- storage deletions recorded in the transaction that stops referencing the
+-- objects, a worker carries them out once it's committed
+CREATE TABLE IF NOT EXISTS storage_outbox (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    operation TEXT NOT NULL,
+    target TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    dead_at TIMESTAMP,
+
+    CONSTRAINT storage_outbox_operation_check CHECK (operation IN ('delete_image', 'delete_object')),
+    CONSTRAINT storage_outbox_status_check CHECK (status IN ('pending', 'dead'))
+);
+
+CREATE INDEX idx_storage_outbox_due ON storage_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_storage_outbox_status ON storage_outbox(status);
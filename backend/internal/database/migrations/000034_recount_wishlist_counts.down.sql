@@ -0,0 +1,2 @@
+-- the recounted values are correct, there is nothing to undo
+SELECT 1;
@@ -0,0 +1,10 @@
+-- deleted accounts left their wishlisted listings counted
+UPDATE listings
+SET wishlist_count = COALESCE(counts.total, 0)
+FROM listings AS l
+LEFT JOIN (
+    SELECT listing_id, COUNT(*) AS total
+    FROM wishlist_listings
+    GROUP BY listing_id
+) AS counts ON counts.listing_id = l.id
+WHERE l.id = listings.id AND listings.wishlist_count <> COALESCE(counts.total, 0);
@@ -0,0 +1,10 @@
+-- suggestions must only come from listings anyone can see, drafts and removed
+-- listings stay private
+DROP MATERIALIZED VIEW IF EXISTS listing_title_words;
+
+CREATE MATERIALIZED VIEW listing_title_words AS
+SELECT word, ndoc
+FROM ts_stat('SELECT to_tsvector(''simple'', title) FROM listings WHERE status IN (''active'', ''reserved'') AND deleted_at IS NULL');
+
+CREATE UNIQUE INDEX idx_listing_title_words_word ON listing_title_words(word);
+CREATE INDEX idx_listing_title_words_trgm ON listing_title_words USING GIN (word gin_trgm_ops);
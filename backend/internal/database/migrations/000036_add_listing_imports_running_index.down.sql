@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_listing_imports_running_user_id;
@@ -0,0 +1,12 @@
+-- a user runs one import at a time, older duplicates left by a race are failed first
+UPDATE listing_imports
+SET status = 'failed', error = 'Another import was running', completed_at = CURRENT_TIMESTAMP
+WHERE status IN ('pending', 'processing') AND EXISTS (
+    SELECT 1 FROM listing_imports newer
+    WHERE newer.user_id = listing_imports.user_id
+        AND newer.status IN ('pending', 'processing')
+        AND newer.id > listing_imports.id
+);
+
+CREATE UNIQUE INDEX idx_listing_imports_running_user_id ON listing_imports(user_id)
+WHERE status IN ('pending', 'processing');
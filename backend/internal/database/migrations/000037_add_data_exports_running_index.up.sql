@@ -0,0 +1,12 @@
+-- a user has one export being prepared at a time, older duplicates left by a race are failed first
+UPDATE data_exports
+SET status = 'failed', error = 'Another export was being prepared', completed_at = CURRENT_TIMESTAMP
+WHERE status IN ('pending', 'processing') AND EXISTS (
+    SELECT 1 FROM data_exports newer
+    WHERE newer.user_id = data_exports.user_id
+        AND newer.status IN ('pending', 'processing')
+        AND newer.id > data_exports.id
+);
+
+CREATE UNIQUE INDEX idx_data_exports_running_user_id ON data_exports(user_id)
+WHERE status IN ('pending', 'processing');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_listing_events_dedup_bucket;
+CREATE INDEX idx_listing_events_dedup ON listing_events(listing_id, type, viewer_key, created_at DESC);
+
+ALTER TABLE listing_events DROP COLUMN IF EXISTS dedup_bucket;
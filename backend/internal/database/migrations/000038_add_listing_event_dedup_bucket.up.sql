@@ -0,0 +1,6 @@
+-- events are deduplicated by a unique key per viewer and time window, so
+-- concurrent requests can't both get in. Older events have no bucket and never conflict.
+ALTER TABLE listing_events ADD COLUMN IF NOT EXISTS dedup_bucket BIGINT;
+
+DROP INDEX IF EXISTS idx_listing_events_dedup;
+CREATE UNIQUE INDEX idx_listing_events_dedup_bucket ON listing_events(listing_id, type, viewer_key, dedup_bucket);
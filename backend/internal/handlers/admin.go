@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
 	"gin-backend/internal/services"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -23,25 +23,45 @@ type AdminUserResponse struct {
 }
 
 type AdminListingResponse struct {
-	ID          uint    `json:"id"`
-	Title       string  `json:"title"`
-	Price       float64 `json:"price"`
-	Category    string  `json:"category"`
-	IsClosed    bool    `json:"is_closed"`
-	UserID      uint    `json:"user_id"`
-	UserEmail   string  `json:"user_email"`
-	UserName    string  `json:"user_name"`
-	ImagesCount int     `json:"images_count"`
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Price       float64    `json:"price"`
+	Currency    string     `json:"currency"`
+	Category    string     `json:"category"`
+	Status      string     `json:"status"`
+	UserID      uint       `json:"user_id"`
+	UserEmail   string     `json:"user_email"`
+	UserName    string     `json:"user_name"`
+	ImagesCount int        `json:"images_count"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy   string     `json:"deleted_by,omitempty"`
 }
 
-// GetAllUsers returns all users with their listing count
+// GetAllUsers returns a page of users with their listing count
 func GetAllUsers(c *gin.Context) {
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec := newestFirst("users")
+	query, err := paginate(database.DB, page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
 	var users []models.User
-	if err := database.DB.Find(&users).Error; err != nil {
+	if err := query.Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
+	users, nextCursor := trimPage(users, page, func(user models.User) string {
+		return encodeCursor(spec, user.CreatedAt, user.ID)
+	})
+
 	var response []AdminUserResponse
 	for _, user := range users {
 		var listingsCount int64
@@ -58,17 +78,40 @@ func GetAllUsers(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"users":       response,
+		"next_cursor": nextCursor,
+	})
 }
 
-// GetAllListings returns all listings with user info
+// GetAllListings returns a page of listings with user info, deleted=true lists the trash instead
 func GetAllListings(c *gin.Context) {
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	base := database.DB.Preload("User")
+	if c.Query("deleted") == "true" {
+		base = base.Unscoped().Where("listings.deleted_at IS NOT NULL")
+	}
+
+	spec := newestFirst("listings")
+	query, err := paginate(base, page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
 	var listings []models.Listing
-	if err := database.DB.Preload("User").Find(&listings).Error; err != nil {
+	if err := query.Find(&listings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
 		return
 	}
 
+	listings, nextCursor := trimPage(listings, page, listingCursor(spec))
+
 	var response []AdminListingResponse
 	for _, listing := range listings {
 		userName := ""
@@ -78,20 +121,36 @@ func GetAllListings(c *gin.Context) {
 			userEmail = listing.User.Email
 		}
 
+		var deletedAt *time.Time
+		if listing.DeletedAt.Valid {
+			deletedAt = &listing.DeletedAt.Time
+		}
+
+		deletedBy := ""
+		if listing.DeletedBy != nil {
+			deletedBy = string(*listing.DeletedBy)
+		}
+
 		response = append(response, AdminListingResponse{
 			ID:          listing.ID,
 			Title:       listing.Title,
 			Price:       listing.Price,
+			Currency:    listing.Currency,
 			Category:    string(listing.Category),
-			IsClosed:    listing.IsClosed,
+			Status:      string(listing.Status),
 			UserID:      listing.UserID,
 			UserEmail:   userEmail,
 			UserName:    userName,
 			ImagesCount: len(listing.ImageURLs),
+			DeletedAt:   deletedAt,
+			DeletedBy:   deletedBy,
 		})
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"listings":    response,
+		"next_cursor": nextCursor,
+	})
 }
 
 // AdminDeleteUser deletes a user and all their data
@@ -105,39 +164,7 @@ func AdminDeleteUser(c *gin.Context) {
 	}
 
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// find user listings
-		var listings []models.Listing
-		if err := tx.Where("user_id = ?", user.ID).Find(&listings).Error; err != nil {
-			return fmt.Errorf("failed to fetch user listings: %w", err)
-		}
-
-		// delete listing images
-		for _, listing := range listings {
-			for _, imgURL := range listing.ImageURLs {
-				if err := services.DeleteImageByURL(c.Request.Context(), imgURL); err != nil {
-					fmt.Printf("warning: failed to delete listing image %s: %v\n", imgURL, err)
-				}
-			}
-		}
-
-		// delete listings
-		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Listing{}).Error; err != nil {
-			return fmt.Errorf("failed to delete user listings: %w", err)
-		}
-
-		// delete user's avatar
-		if user.AvatarURL != "" {
-			if err := services.DeleteImageByURL(c.Request.Context(), user.AvatarURL); err != nil {
-				fmt.Printf("warning: failed to delete user avatar %s: %v\n", user.AvatarURL, err)
-			}
-		}
-
-		// delete user
-		if err := tx.Delete(&user).Error; err != nil {
-			return fmt.Errorf("failed to delete user: %w", err)
-		}
-
-		return nil
+		return services.DeleteUserAccount(tx, user)
 	})
 
 	if err != nil {
@@ -145,6 +172,8 @@ func AdminDeleteUser(c *gin.Context) {
 		return
 	}
 
+	services.WakeStorageOutbox()
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": fmt.Sprintf("User %s deleted successfully", user.Email),
@@ -161,19 +190,12 @@ func AdminDeleteListing(c *gin.Context) {
 		return
 	}
 
-	// delete listing in db
-	if err := database.DB.Delete(&listing).Error; err != nil {
+	// move listing to the trash, images are purged once it can no longer be restored
+	if err := softDeleteListing(&listing, models.ListingActorAdmin); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// delete listing images in storage
-	for _, imgURL := range listing.ImageURLs {
-		if err := services.DeleteImageByURL(context.Background(), imgURL); err != nil {
-			fmt.Printf("warning: failed to delete image: %v\n", err)
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": fmt.Sprintf("Listing '%s' deleted successfully", listing.Title),
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"gin-backend/internal/models"
 	"gin-backend/internal/services"
 	"mime/multipart"
 	"net/http"
@@ -17,6 +18,8 @@ type PriceSuggestionRequest struct {
 	Images      []*multipart.FileHeader `form:"images[]"`
 	ImageUrls   []string                `form:"image_urls[]"`
 	Language    string                  `form:"lang" binding:"required"`
+	Condition   string                  `form:"condition"`
+	Currency    string                  `form:"currency"`
 }
 
 func AskAIAboutPrice(c *gin.Context) {
@@ -49,7 +52,19 @@ func AskAIAboutPrice(c *gin.Context) {
 		return
 	}
 
-	priceSuggestionResponse, err := services.SuggestPrice(ctx, body.Title, body.Language, body.Description, body.Images, body.ImageUrls)
+	condition, err := parseListingCondition(body.Condition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := viewerCurrency(c, body.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	priceSuggestionResponse, err := services.SuggestPrice(ctx, body.Title, body.Language, body.Description, conditionOrUnknown(condition), currency, body.Images, body.ImageUrls)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -58,6 +73,14 @@ func AskAIAboutPrice(c *gin.Context) {
 	c.JSON(http.StatusOK, priceSuggestionResponse)
 }
 
+// conditionOrUnknown returns an empty condition when the seller didn't state one
+func conditionOrUnknown(condition *models.ListingCondition) models.ListingCondition {
+	if condition == nil {
+		return ""
+	}
+	return *condition
+}
+
 func HealthCheckGemini(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pickupDistanceSQL is the great-circle distance in km from a point to a listing's pickup location
+const pickupDistanceSQL = `6371 * acos(LEAST(1,
+	cos(radians(?)) * cos(radians(campus_locations.latitude)) * cos(radians(campus_locations.longitude) - radians(?)) +
+	sin(radians(?)) * sin(radians(campus_locations.latitude))
+))`
+
+type CreateCampusLocationDTO struct {
+	University string   `json:"university" binding:"required"`
+	Name       string   `json:"name" binding:"required"`
+	Kind       string   `json:"kind"`
+	Address    string   `json:"address"`
+	Latitude   *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude  *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+type UpdateCampusLocationDTO struct {
+	Name      *string  `json:"name"`
+	Kind      *string  `json:"kind"`
+	Address   *string  `json:"address"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+	Active    *bool    `json:"active"`
+}
+
+// findPickupLocation returns a location sellers can still pick
+func findPickupLocation(id uint) (models.CampusLocation, error) {
+	var location models.CampusLocation
+	if err := database.DB.First(&location, "id = ? AND active", id).Error; err != nil {
+		return location, errors.New("Invalid pickup location")
+	}
+	return location, nil
+}
+
+// parseListingCondition accepts an empty value as "not stated"
+func parseListingCondition(value string) (*models.ListingCondition, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	condition := models.ListingCondition(strings.TrimSpace(value))
+	if !condition.Valid() {
+		return nil, errors.New("Condition must be one of new, like_new, good, fair, for_parts")
+	}
+	return &condition, nil
+}
+
+// GetCampusLocations lists active pickup locations, optionally for one university
+func GetCampusLocations(c *gin.Context) {
+	query := database.DB.Where("active")
+	if university := strings.TrimSpace(c.Query("university")); university != "" {
+		query = query.Where("LOWER(university) = LOWER(?)", university)
+	}
+
+	var locations []models.CampusLocation
+	if err := query.Order("university, kind, name").Find(&locations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campus locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// AdminGetCampusLocations lists every location, inactive ones included
+func AdminGetCampusLocations(c *gin.Context) {
+	var locations []models.CampusLocation
+	if err := database.DB.Order("university, kind, name").Find(&locations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campus locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+func AdminCreateCampusLocation(c *gin.Context) {
+	var body CreateCampusLocationDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	if strings.TrimSpace(body.University) == "" || strings.TrimSpace(body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "University and name cannot be empty"})
+		return
+	}
+
+	kind := models.CampusBuilding
+	if body.Kind != "" {
+		kind = models.CampusLocationKind(body.Kind)
+	}
+	if !kind.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be one of building, dorm, other"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.CampusLocation{}).
+		Where("university = ? AND name = ?", strings.TrimSpace(body.University), strings.TrimSpace(body.Name)).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Location already exists at this university"})
+		return
+	}
+
+	location := models.CampusLocation{
+		University: strings.TrimSpace(body.University),
+		Name:       strings.TrimSpace(body.Name),
+		Kind:       kind,
+		Address:    strings.TrimSpace(body.Address),
+		Latitude:   *body.Latitude,
+		Longitude:  *body.Longitude,
+		Active:     true,
+	}
+	if err := database.DB.Create(&location).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create campus location"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"location": location,
+	})
+}
+
+// AdminUpdateCampusLocation edits a location, setting active to false retires it
+func AdminUpdateCampusLocation(c *gin.Context) {
+	var location models.CampusLocation
+	if err := database.DB.First(&location, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campus location not found"})
+		return
+	}
+
+	var body UpdateCampusLocationDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.Name != nil {
+		if strings.TrimSpace(*body.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		location.Name = strings.TrimSpace(*body.Name)
+	}
+
+	if body.Kind != nil {
+		kind := models.CampusLocationKind(*body.Kind)
+		if !kind.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be one of building, dorm, other"})
+			return
+		}
+		location.Kind = kind
+	}
+
+	if body.Address != nil {
+		location.Address = strings.TrimSpace(*body.Address)
+	}
+
+	if body.Latitude != nil {
+		location.Latitude = *body.Latitude
+	}
+
+	if body.Longitude != nil {
+		location.Longitude = *body.Longitude
+	}
+
+	if body.Active != nil {
+		location.Active = *body.Active
+	}
+
+	if err := database.DB.Save(&location).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update campus location"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"location": location,
+	})
+}
+
+// AdminDeleteCampusLocation removes an unused location, used ones should be deactivated instead
+func AdminDeleteCampusLocation(c *gin.Context) {
+	var location models.CampusLocation
+	if err := database.DB.First(&location, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campus location not found"})
+		return
+	}
+
+	var listings int64
+	database.DB.Unscoped().Model(&models.Listing{}).Where("pickup_location_id = ?", location.ID).Count(&listings)
+	if listings > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Location is used by listings, deactivate it instead"})
+		return
+	}
+
+	if err := database.DB.Delete(&location).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// categorySubtreeSQL selects the slug of a category and of everything below it
+const categorySubtreeSQL = `
+	WITH RECURSIVE subtree AS (
+		SELECT id, slug FROM categories WHERE slug = LOWER(?)
+		UNION ALL
+		SELECT c.id, c.slug FROM categories c JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT slug FROM subtree`
+
+type CreateCategoryDTO struct {
+	Slug       string                `json:"slug" binding:"required"`
+	ParentID   *uint                 `json:"parent_id"`
+	Names      models.LocalizedNames `json:"names" binding:"required"`
+	Icon       string                `json:"icon"`
+	SortOrder  int                   `json:"sort_order"`
+	ExpiryDays *int                  `json:"expiry_days" binding:"omitempty,min=1"`
+}
+
+// UpdateCategoryDTO changes only the fields that are set. parent_id 0 moves the
+// category to the top level, expiry_days 0 makes it inherit the period again.
+type UpdateCategoryDTO struct {
+	Slug       *string               `json:"slug"`
+	ParentID   *uint                 `json:"parent_id"`
+	Names      models.LocalizedNames `json:"names"`
+	Icon       *string               `json:"icon"`
+	SortOrder  *int                  `json:"sort_order"`
+	ExpiryDays *int                  `json:"expiry_days" binding:"omitempty,min=0"`
+}
+
+type CategoryNode struct {
+	models.Category
+	Name     string         `json:"name"`
+	Children []CategoryNode `json:"children"`
+}
+
+// findCategory looks a category up by slug, case-insensitively
+func findCategory(slug string) (models.Category, error) {
+	var category models.Category
+	err := database.DB.First(&category, "slug = ?", strings.ToLower(strings.TrimSpace(slug))).Error
+	return category, err
+}
+
+func validateCategoryNames(names models.LocalizedNames) error {
+	if strings.TrimSpace(names[models.DefaultCategoryLanguage]) == "" {
+		return errors.New("An English name is required")
+	}
+	return nil
+}
+
+// GetCategories returns the category tree, names are resolved for ?lang= (default en)
+func GetCategories(c *gin.Context) {
+	lang := c.DefaultQuery("lang", models.DefaultCategoryLanguage)
+
+	var categories []models.Category
+	if err := database.DB.Order("sort_order, slug").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	children := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(categories []models.Category) []CategoryNode
+	build = func(categories []models.Category) []CategoryNode {
+		nodes := []CategoryNode{}
+		for _, category := range categories {
+			nodes = append(nodes, CategoryNode{
+				Category: category,
+				Name:     category.Names.Get(lang),
+				Children: build(children[category.ID]),
+			})
+		}
+		return nodes
+	}
+
+	c.JSON(http.StatusOK, build(roots))
+}
+
+// AdminGetCategories returns every category as a flat list
+func AdminGetCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := database.DB.Order("sort_order, slug").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// AdminCreateCategory adds a category, optionally under a parent
+func AdminCreateCategory(c *gin.Context) {
+	var body CreateCategoryDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	slug := strings.ToLower(strings.TrimSpace(body.Slug))
+	if !categorySlugPattern.MatchString(slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slug may only contain lowercase letters, digits and dashes"})
+		return
+	}
+
+	if err := validateCategoryNames(body.Names); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.ParentID != nil {
+		var parent models.Category
+		if err := database.DB.First(&parent, *body.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+	}
+
+	if _, err := findCategory(slug); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A category with this slug already exists"})
+		return
+	}
+
+	category := models.Category{
+		ParentID:   body.ParentID,
+		Slug:       slug,
+		Names:      body.Names,
+		Icon:       body.Icon,
+		SortOrder:  body.SortOrder,
+		ExpiryDays: body.ExpiryDays,
+	}
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"category": category,
+	})
+}
+
+// AdminUpdateCategory renames, moves or reorders a category. Listings follow a
+// slug change through the foreign key, saved searches and revision snapshots are
+// updated here.
+func AdminUpdateCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+	if err := database.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var body UpdateCategoryDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	oldSlug := category.Slug
+	if body.Slug != nil {
+		slug := strings.ToLower(strings.TrimSpace(*body.Slug))
+		if !categorySlugPattern.MatchString(slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Slug may only contain lowercase letters, digits and dashes"})
+			return
+		}
+
+		if existing, err := findCategory(slug); err == nil && existing.ID != category.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "A category with this slug already exists"})
+			return
+		}
+		category.Slug = slug
+	}
+
+	if body.Names != nil {
+		if err := validateCategoryNames(body.Names); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		category.Names = body.Names
+	}
+
+	if body.ParentID != nil {
+		if *body.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			// a category cannot be moved below itself
+			var subtree []uint
+			database.DB.Raw(`
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM categories WHERE id = ?
+					UNION ALL
+					SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+				)
+				SELECT id FROM subtree`, category.ID).Scan(&subtree)
+			for _, id := range subtree {
+				if id == *body.ParentID {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be moved under itself"})
+					return
+				}
+			}
+
+			var parent models.Category
+			if err := database.DB.First(&parent, *body.ParentID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+				return
+			}
+			category.ParentID = &parent.ID
+		}
+	}
+
+	if body.Icon != nil {
+		category.Icon = *body.Icon
+	}
+
+	if body.SortOrder != nil {
+		category.SortOrder = *body.SortOrder
+	}
+
+	if body.ExpiryDays != nil {
+		if *body.ExpiryDays == 0 {
+			category.ExpiryDays = nil
+		} else {
+			category.ExpiryDays = body.ExpiryDays
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+
+		if category.Slug == oldSlug {
+			return nil
+		}
+
+		if err := tx.Exec(
+			"UPDATE saved_searches SET params = jsonb_set(params, '{category}', to_jsonb(?::text)) WHERE LOWER(params->>'category') = ?",
+			category.Slug, oldSlug,
+		).Error; err != nil {
+			return err
+		}
+
+		// snapshots are JSONB, the foreign key doesn't reach them
+		return tx.Exec(
+			"UPDATE listing_revisions SET snapshot = jsonb_set(snapshot, '{category}', to_jsonb(?::text)) WHERE snapshot->>'category' = ?",
+			category.Slug, oldSlug,
+		).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"category": category,
+	})
+}
+
+// AdminDeleteCategory removes a category that has no subcategories and no listings
+func AdminDeleteCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+	if err := database.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var childCount int64
+	database.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&childCount)
+	if childCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Move or delete the subcategories first"})
+		return
+	}
+
+	// deleted listings still reference their category
+	var listingCount int64
+	database.DB.Unscoped().Model(&models.Listing{}).Where("category = ?", category.Slug).Count(&listingCount)
+	if listingCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category still has listings"})
+		return
+	}
+
+	if err := database.DB.Delete(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
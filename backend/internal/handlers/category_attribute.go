@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	attributeFilterPrefix  = "attr."
+	maxAttributeTextLength = 200
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type CreateCategoryAttributeDTO struct {
+	Key        string                `json:"key" binding:"required"`
+	Names      models.LocalizedNames `json:"names" binding:"required"`
+	Type       string                `json:"type" binding:"required"`
+	Required   bool                  `json:"required"`
+	EnumValues []string              `json:"enum_values"`
+	SortOrder  int                   `json:"sort_order"`
+}
+
+type UpdateCategoryAttributeDTO struct {
+	Names      models.LocalizedNames `json:"names"`
+	Required   *bool                 `json:"required"`
+	EnumValues []string              `json:"enum_values"`
+	SortOrder  *int                  `json:"sort_order"`
+}
+
+// categoryAttributes returns the attribute schema of a category, including the
+// attributes inherited from its parents. Closer categories override by key.
+func categoryAttributes(slug string) ([]models.CategoryAttribute, error) {
+	var attributes []models.CategoryAttribute
+	err := database.DB.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE slug = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT DISTINCT ON (category_attributes.key) category_attributes.*
+		FROM category_attributes
+		JOIN ancestors ON ancestors.id = category_attributes.category_id
+		ORDER BY category_attributes.key, ancestors.depth`,
+		slug,
+	).Scan(&attributes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// parseListingAttributes decodes the attributes form field, a JSON object
+func parseListingAttributes(raw string) (models.ListingAttributes, error) {
+	attributes := models.ListingAttributes{}
+	if strings.TrimSpace(raw) == "" {
+		return attributes, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &attributes); err != nil {
+		return nil, errors.New("Invalid attributes format")
+	}
+	return attributes, nil
+}
+
+// validateListingAttributes checks values against the category schema and returns
+// them normalized: text trimmed, enum values in their defined spelling, ISBNs as digits
+func validateListingAttributes(schema []models.CategoryAttribute, values models.ListingAttributes) (models.ListingAttributes, error) {
+	byKey := make(map[string]models.CategoryAttribute, len(schema))
+	for _, attribute := range schema {
+		byKey[attribute.Key] = attribute
+	}
+
+	for key := range values {
+		if _, ok := byKey[key]; !ok {
+			return nil, fmt.Errorf("Unknown attribute %q for this category", key)
+		}
+	}
+
+	normalized := models.ListingAttributes{}
+	for _, attribute := range schema {
+		value, ok := values[attribute.Key]
+		if !ok || value == nil || value == "" {
+			if attribute.Required {
+				return nil, fmt.Errorf("Attribute %q is required", attribute.Key)
+			}
+			continue
+		}
+
+		clean, err := normalizeAttributeValue(attribute, value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[attribute.Key] = clean
+	}
+
+	return normalized, nil
+}
+
+func normalizeAttributeValue(attribute models.CategoryAttribute, value any) (any, error) {
+	switch attribute.Type {
+	case models.AttributeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("Attribute %q must be a number", attribute.Key)
+		}
+		return number, nil
+
+	case models.AttributeBoolean:
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("Attribute %q must be true or false", attribute.Key)
+		}
+		return flag, nil
+
+	case models.AttributeEnum:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Attribute %q must be one of %s", attribute.Key, strings.Join(attribute.EnumValues, ", "))
+		}
+		for _, allowed := range attribute.EnumValues {
+			if strings.EqualFold(strings.TrimSpace(text), allowed) {
+				return allowed, nil
+			}
+		}
+		return nil, fmt.Errorf("Attribute %q must be one of %s", attribute.Key, strings.Join(attribute.EnumValues, ", "))
+
+	case models.AttributeISBN:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Attribute %q must be an ISBN", attribute.Key)
+		}
+		isbn, err := normalizeISBN(text)
+		if err != nil {
+			return nil, fmt.Errorf("Attribute %q: %v", attribute.Key, err)
+		}
+		return isbn, nil
+
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Attribute %q must be text", attribute.Key)
+		}
+		text = strings.TrimSpace(text)
+		if len(text) > maxAttributeTextLength {
+			return nil, fmt.Errorf("Attribute %q is too long", attribute.Key)
+		}
+		return text, nil
+	}
+}
+
+// normalizeISBN strips dashes and spaces and verifies the ISBN-10 or ISBN-13 check digit
+func normalizeISBN(value string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, r := range isbn {
+			var digit int
+			switch {
+			case r >= '0' && r <= '9':
+				digit = int(r - '0')
+			case r == 'X' && i == 9:
+				digit = 10
+			default:
+				return "", errors.New("invalid ISBN")
+			}
+			sum += digit * (10 - i)
+		}
+		if sum%11 != 0 {
+			return "", errors.New("invalid ISBN check digit")
+		}
+	case 13:
+		sum := 0
+		for i, r := range isbn {
+			if r < '0' || r > '9' {
+				return "", errors.New("invalid ISBN")
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += int(r-'0') * weight
+		}
+		if sum%10 != 0 {
+			return "", errors.New("invalid ISBN check digit")
+		}
+	default:
+		return "", errors.New("ISBN must have 10 or 13 digits")
+	}
+
+	return isbn, nil
+}
+
+// parseAttributeFilters collects attr.<key>=<value> query parameters
+func parseAttributeFilters(query url.Values) (map[string]string, error) {
+	filters := map[string]string{}
+	for name, values := range query {
+		if !strings.HasPrefix(name, attributeFilterPrefix) || len(values) == 0 {
+			continue
+		}
+
+		key := strings.TrimPrefix(name, attributeFilterPrefix)
+		if !attributeKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid attribute filter %q", name)
+		}
+
+		if value := strings.TrimSpace(values[0]); value != "" {
+			filters[key] = value
+		}
+	}
+	return filters, nil
+}
+
+// AttributeFilter is an attr.<key> filter checked against the category schema,
+// Value is nil when the given value doesn't fit the attribute type
+type AttributeFilter struct {
+	Key   string
+	Type  models.AttributeType
+	Value any
+}
+
+// resolveAttributeFilters checks attr.<key> filters against the schema of the
+// searched category and converts their values to the attribute types
+func resolveAttributeFilters(category string, filters map[string]string) ([]AttributeFilter, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	if category == "" {
+		return nil, errors.New("attribute filters need a category")
+	}
+
+	schema, err := categoryAttributes(category)
+	if err != nil {
+		return nil, errCategoryAttributesUnavailable
+	}
+
+	byKey := make(map[string]models.CategoryAttribute, len(schema))
+	for _, attribute := range schema {
+		byKey[attribute.Key] = attribute
+	}
+
+	for key := range filters {
+		if _, ok := byKey[key]; !ok {
+			return nil, fmt.Errorf("unknown attribute %q for this category", key)
+		}
+	}
+
+	resolved := make([]AttributeFilter, 0, len(filters))
+
+	// schema order keeps the generated conditions stable
+	for _, attribute := range schema {
+		value, ok := filters[attribute.Key]
+		if !ok {
+			continue
+		}
+		resolved = append(resolved, AttributeFilter{
+			Key:   attribute.Key,
+			Type:  attribute.Type,
+			Value: attributeFilterValue(attribute, value),
+		})
+	}
+
+	return resolved, nil
+}
+
+// attributeFilterValue converts a filter value to the attribute type, text is
+// kept as given since it matches substrings
+func attributeFilterValue(attribute models.CategoryAttribute, value string) any {
+	switch attribute.Type {
+	case models.AttributeNumber:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case models.AttributeBoolean:
+		if flag, err := strconv.ParseBool(value); err == nil {
+			return flag
+		}
+	case models.AttributeEnum:
+		if clean, err := normalizeAttributeValue(attribute, value); err == nil {
+			return clean
+		}
+	case models.AttributeISBN:
+		if isbn, err := normalizeISBN(value); err == nil {
+			return isbn
+		}
+	default:
+		return value
+	}
+	return nil
+}
+
+// attributeFilterCondition turns one attribute filter into a WHERE condition. Typed
+// values use JSONB containment so the GIN index applies, free text matches substrings.
+func attributeFilterCondition(filter AttributeFilter) (string, []interface{}) {
+	// a value that doesn't fit the attribute type can't match anything
+	if filter.Value == nil {
+		return "FALSE", nil
+	}
+
+	if filter.Type == models.AttributeText {
+		return "listings.attributes->>? ILIKE ?", []interface{}{filter.Key, "%" + escapeLike(filter.Value.(string)) + "%"}
+	}
+
+	containment, _ := json.Marshal(map[string]any{filter.Key: filter.Value})
+	return "listings.attributes @> ?::jsonb", []interface{}{string(containment)}
+}
+
+// GetCategoryAttributes returns the attribute schema listings in a category must follow
+func GetCategoryAttributes(c *gin.Context) {
+	category, err := findCategory(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	attributes, err := categoryAttributes(category.Slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attributes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+// AdminGetCategoryAttributes lists the attributes defined directly on a category
+func AdminGetCategoryAttributes(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+	if err := database.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var attributes []models.CategoryAttribute
+	if err := database.DB.Where("category_id = ?", category.ID).Order("sort_order, key").Find(&attributes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attributes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+// AdminCreateCategoryAttribute adds an attribute to a category's schema
+func AdminCreateCategoryAttribute(c *gin.Context) {
+	categoryID := c.Param("id")
+
+	var category models.Category
+	if err := database.DB.First(&category, "id = ?", categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var body CreateCategoryAttributeDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	if !attributeKeyPattern.MatchString(body.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Key may only contain lowercase letters, digits and underscores"})
+		return
+	}
+
+	attributeType := models.AttributeType(body.Type)
+	if !attributeType.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be one of text, number, boolean, enum, isbn"})
+		return
+	}
+
+	if attributeType == models.AttributeEnum && len(body.EnumValues) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Enum attributes need at least one value"})
+		return
+	}
+
+	if err := validateCategoryNames(body.Names); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.CategoryAttribute{}).Where("category_id = ? AND key = ?", category.ID, body.Key).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attribute already exists in this category"})
+		return
+	}
+
+	attribute := models.CategoryAttribute{
+		CategoryID: category.ID,
+		Key:        body.Key,
+		Names:      body.Names,
+		Type:       attributeType,
+		Required:   body.Required,
+		EnumValues: body.EnumValues,
+		SortOrder:  body.SortOrder,
+	}
+	if attribute.EnumValues == nil {
+		attribute.EnumValues = []string{}
+	}
+	if err := database.DB.Create(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attribute"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"attribute": attribute,
+	})
+}
+
+// AdminUpdateCategoryAttribute changes an attribute's names, enum values, order or
+// whether it is required. Key and type are fixed since listings already store values.
+func AdminUpdateCategoryAttribute(c *gin.Context) {
+	var attribute models.CategoryAttribute
+	if err := database.DB.First(&attribute, "id = ? AND category_id = ?", c.Param("attributeId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return
+	}
+
+	var body UpdateCategoryAttributeDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.Names != nil {
+		if err := validateCategoryNames(body.Names); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		attribute.Names = body.Names
+	}
+
+	if body.EnumValues != nil {
+		if attribute.Type != models.AttributeEnum {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only enum attributes have values"})
+			return
+		}
+		if len(body.EnumValues) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Enum attributes need at least one value"})
+			return
+		}
+		attribute.EnumValues = body.EnumValues
+	}
+
+	if body.Required != nil {
+		attribute.Required = *body.Required
+	}
+
+	if body.SortOrder != nil {
+		attribute.SortOrder = *body.SortOrder
+	}
+
+	if err := database.DB.Save(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attribute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"attribute": attribute,
+	})
+}
+
+// AdminDeleteCategoryAttribute removes an attribute from the schema, stored values are kept
+func AdminDeleteCategoryAttribute(c *gin.Context) {
+	var attribute models.CategoryAttribute
+	if err := database.DB.First(&attribute, "id = ? AND category_id = ?", c.Param("attributeId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
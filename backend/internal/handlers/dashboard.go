@@ -3,22 +3,27 @@ package handlers
 import (
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
+	"gin-backend/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type DashboardStats struct {
-	TotalListings  int64   `json:"total_listings"`
-	ActiveListings int64   `json:"active_listings"`
-	ClosedListings int64   `json:"closed_listings"`
-	TotalWishlists int64   `json:"total_wishlists"`
-	AveragePrice   float64 `json:"average_price"`
+	TotalListings  int64                          `json:"total_listings"`
+	ActiveListings int64                          `json:"active_listings"`
+	StatusCounts   map[models.ListingStatus]int64 `json:"status_counts"`
+	TotalWishlists int64                          `json:"total_wishlists"`
+	AveragePrice   float64                        `json:"average_price"`
+	Currency       string                         `json:"currency"`
 }
 
 type DashboardData struct {
 	Stats    DashboardStats    `json:"stats"`
 	Listings []ListingResponse `json:"listings"`
+	// Activity is the daily views, wishlist adds and contact clicks of all the user's listings
+	Activity []DailyStatsPoint `json:"activity"`
 	Ratings  struct {
 		Ratings       []models.Rating `json:"ratings"`
 		AverageRating float64         `json:"average_rating"`
@@ -47,16 +52,49 @@ func GetDashboard(c *gin.Context) {
 
 	// Get stats
 	database.DB.Model(&models.Listing{}).Where("user_id = ?", user.ID).Count(&dashboardData.Stats.TotalListings)
-	database.DB.Model(&models.Listing{}).Where("user_id = ? AND is_closed = ?", user.ID, false).Count(&dashboardData.Stats.ActiveListings)
-	database.DB.Model(&models.Listing{}).Where("user_id = ? AND is_closed = ?", user.ID, true).Count(&dashboardData.Stats.ClosedListings)
+	var statusCounts []struct {
+		Status models.ListingStatus
+		Count  int64
+	}
+	database.DB.Model(&models.Listing{}).
+		Where("user_id = ?", user.ID).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&statusCounts)
+
+	// every status is present so sold and archived can be told apart even at zero
+	dashboardData.Stats.StatusCounts = make(map[models.ListingStatus]int64)
+	for _, status := range models.ListingStatuses {
+		dashboardData.Stats.StatusCounts[status] = 0
+	}
+	for _, row := range statusCounts {
+		dashboardData.Stats.StatusCounts[row.Status] = row.Count
+	}
+	dashboardData.Stats.ActiveListings = dashboardData.Stats.StatusCounts[models.ListingActive]
 	database.DB.Table("wishlist_listings").
 		Joins("JOIN listings ON wishlist_listings.listing_id = listings.id").
-		Where("listings.user_id = ?", user.ID).
+		Where("listings.user_id = ? AND listings.deleted_at IS NULL", user.ID).
 		Count(&dashboardData.Stats.TotalWishlists)
+
+	// the average is taken in the base currency and shown in the user's preferred one
+	dashboardData.Stats.Currency, _ = viewerCurrency(c, "")
+	var averageBase float64
 	database.DB.Model(&models.Listing{}).
 		Where("user_id = ?", user.ID).
-		Select("COALESCE(AVG(price), 0)").
-		Scan(&dashboardData.Stats.AveragePrice)
+		Select("COALESCE(AVG(price_base), 0)").
+		Scan(&averageBase)
+	if rates, err := services.LoadExchangeRates(); err == nil {
+		dashboardData.Stats.AveragePrice, _ = rates.Convert(averageBase, models.BaseCurrency, dashboardData.Stats.Currency)
+	}
+
+	// daily activity over the user's listings, deleted ones included since their views still happened
+	from, to := analyticsRange(defaultAnalyticsDays)
+	activity, _, err := loadStatsSeries(database.DB.Unscoped().Model(&models.Listing{}).Select("id").Where("user_id = ?", user.ID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	dashboardData.Activity = activity
 
 	// Get user's listings
 	var listings []models.Listing
@@ -76,7 +114,10 @@ func GetDashboard(c *gin.Context) {
 	var ratings []models.Rating
 	if err := database.DB.Where("user_id = ?", user.ID).
 		Preload("Rater").
-		Preload("Listing").
+		Preload("Listing", func(db *gorm.DB) *gorm.DB {
+			// ratings keep showing the listing they were given for after it is deleted
+			return db.Unscoped()
+		}).
 		Order("created_at DESC").
 		Find(&ratings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
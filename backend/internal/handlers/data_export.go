@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+type DataExportResponse struct {
+	Export      models.DataExport `json:"export"`
+	DownloadURL string            `json:"download_url,omitempty"`
+	URLExpires  *time.Time        `json:"download_url_expires_at,omitempty"`
+}
+
+// RequestDataExport starts building an archive of everything stored about the user
+func RequestDataExport(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	// only one export at a time, a unique index keeps concurrent requests from
+	// both starting one
+	export := models.DataExport{
+		UserID: user.ID,
+		Status: models.DataExportPending,
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&export)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An export is already being prepared"})
+		return
+	}
+
+	go services.BuildDataExport(context.Background(), export.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"export":  export,
+	})
+}
+
+// GetDataExports lists the user's exports, newest first
+func GetDataExports(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var exports []models.DataExport
+	if err := database.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&exports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+// GetDataExport returns an export's status and, once ready, a short-lived download link
+func GetDataExport(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	exportID := c.Param("id")
+	var export models.DataExport
+	if err := database.DB.First(&export, "id = ? AND user_id = ?", exportID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	response := DataExportResponse{Export: export}
+
+	if export.Status == models.DataExportReady && export.ExpiresAt != nil && export.ExpiresAt.After(time.Now()) {
+		// link never outlives the archive
+		ttl := services.DataExportLinkTTL
+		if remaining := time.Until(*export.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+
+		filename := fmt.Sprintf("data-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+		url, err := services.PresignDownloadURL(c.Request.Context(), export.ObjectKey, filename, ttl)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download link"})
+			return
+		}
+
+		urlExpires := time.Now().Add(ttl)
+		response.DownloadURL = url
+		response.URLExpires = &urlExpires
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxExchangeRateFileSize = 1 << 20
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type UpdateExchangeRateDTO struct {
+	Rate float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// ConvertedPrice is a listing's price in the viewer's currency
+type ConvertedPrice struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// normalizeCurrency uppercases a currency code and checks that a rate exists for it
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyCodePattern.MatchString(currency) {
+		return "", errors.New("Currency must be a 3-letter ISO code")
+	}
+
+	var count int64
+	database.DB.Model(&models.ExchangeRate{}).Where("currency = ?", currency).Count(&count)
+	if count == 0 {
+		return "", fmt.Errorf("Unsupported currency %s", currency)
+	}
+	return currency, nil
+}
+
+// viewerCurrency picks the currency to show prices in: the requested one, the
+// signed in user's preferred one or the base currency
+func viewerCurrency(c *gin.Context, requested string) (string, error) {
+	if strings.TrimSpace(requested) != "" {
+		return normalizeCurrency(requested)
+	}
+
+	if userAny, exists := c.Get("user"); exists {
+		if user, ok := userAny.(models.User); ok && user.PreferredCurrency != nil {
+			return *user.PreferredCurrency, nil
+		}
+	}
+
+	return models.BaseCurrency, nil
+}
+
+// convertListingPrice returns nil when the listing is already priced in currency
+func convertListingPrice(rates services.ExchangeRates, listing models.Listing, currency string) *ConvertedPrice {
+	if listing.Currency == currency {
+		return nil
+	}
+
+	amount, ok := rates.Convert(listing.Price, listing.Currency, currency)
+	if !ok {
+		return nil
+	}
+	return &ConvertedPrice{Amount: amount, Currency: currency}
+}
+
+// GetCurrencies lists the currencies listings can be priced in, with their rates
+func GetCurrencies(c *gin.Context) {
+	var rates []models.ExchangeRate
+	if err := database.DB.Order("currency").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch currencies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base":  models.BaseCurrency,
+		"rates": rates,
+	})
+}
+
+// AdminUpdateExchangeRate sets the rate of one currency, adding it if it's new
+func AdminUpdateExchangeRate(c *gin.Context) {
+	currency := strings.ToUpper(c.Param("currency"))
+	if !currencyCodePattern.MatchString(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Currency must be a 3-letter ISO code"})
+		return
+	}
+
+	if currency == models.BaseCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The base currency rate is always 1"})
+		return
+	}
+
+	var body UpdateExchangeRateDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.SaveExchangeRates(tx, services.ExchangeRates{currency: body.Rate}, "manual")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// AdminDeleteExchangeRate removes a currency nothing is priced in anymore
+func AdminDeleteExchangeRate(c *gin.Context) {
+	currency := strings.ToUpper(c.Param("currency"))
+	if currency == models.BaseCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The base currency cannot be removed"})
+		return
+	}
+
+	var rate models.ExchangeRate
+	if err := database.DB.First(&rate, "currency = ?", currency).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Currency not found"})
+		return
+	}
+
+	var listings int64
+	database.DB.Unscoped().Model(&models.Listing{}).Where("currency = ?", currency).Count(&listings)
+	if listings > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Listings are priced in this currency"})
+		return
+	}
+
+	var templates int64
+	database.DB.Model(&models.ListingTemplate{}).Where("currency = ?", currency).Count(&templates)
+	if templates > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Listing templates are priced in this currency"})
+		return
+	}
+
+	if err := database.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// AdminImportExchangeRates adds or updates the rates in an uploaded file, currencies
+// missing from it keep their rate and are reported back. CSV files have
+// currency,rate rows, JSON files are {"base": "USD", "rates": {"KZT": 500, ...}}
+// as most rate providers return them. Rates are units per one base currency.
+func AdminImportExchangeRates(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	if fileHeader.Size > maxExchangeRateFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	var rates services.ExchangeRates
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		rates, err = parseExchangeRatesCSV(file)
+	case ".json":
+		rates, err = parseExchangeRatesJSON(file)
+	default:
+		err = errors.New("File must be .csv or .json")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the base currency is fixed at 1, providers include it anyway
+	delete(rates, models.BaseCurrency)
+	if len(rates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File contains no rates"})
+		return
+	}
+
+	notUpdated := []string{}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var currencies []string
+		if err := tx.Model(&models.ExchangeRate{}).
+			Where("currency <> ?", models.BaseCurrency).
+			Order("currency").
+			Pluck("currency", &currencies).Error; err != nil {
+			return err
+		}
+		for _, currency := range currencies {
+			if _, ok := rates[currency]; !ok {
+				notUpdated = append(notUpdated, currency)
+			}
+		}
+
+		return services.SaveExchangeRates(tx, rates, "import:"+fileHeader.Filename)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"imported":    len(rates),
+		"not_updated": notUpdated,
+	})
+}
+
+func parseExchangeRatesCSV(r io.Reader) (services.ExchangeRates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+
+	rates := services.ExchangeRates{}
+	for i, record := range records {
+		currency := strings.ToUpper(strings.TrimSpace(record[0]))
+
+		// a header row is allowed
+		if i == 0 && !currencyCodePattern.MatchString(currency) {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rate on line %d", i+1)
+		}
+		if err := addImportedRate(rates, currency, rate); err != nil {
+			return nil, fmt.Errorf("Line %d: %v", i+1, err)
+		}
+	}
+	return rates, nil
+}
+
+func parseExchangeRatesJSON(r io.Reader) (services.ExchangeRates, error) {
+	var body struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return nil, errors.New("Invalid JSON")
+	}
+
+	if body.Base != "" && strings.ToUpper(body.Base) != models.BaseCurrency {
+		return nil, fmt.Errorf("Rates must be quoted against %s", models.BaseCurrency)
+	}
+
+	rates := services.ExchangeRates{}
+	for currency, rate := range body.Rates {
+		if err := addImportedRate(rates, strings.ToUpper(currency), rate); err != nil {
+			return nil, err
+		}
+	}
+	return rates, nil
+}
+
+func addImportedRate(rates services.ExchangeRates, currency string, rate float64) error {
+	if !currencyCodePattern.MatchString(currency) {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	// ParseFloat accepts NaN and Inf, neither is a usable rate
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return fmt.Errorf("rate for %s must be a positive number", currency)
+	}
+	rates[currency] = rate
+	return nil
+}
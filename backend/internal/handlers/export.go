@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-backend/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	// rows written between flushes, so the download starts before the query ends
+	exportFlushEvery = 100
+)
+
+// ExportParams picks the format and the columns of an export, columns is a comma
+// separated list of column names in the order they should appear
+type ExportParams struct {
+	Format  string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	Columns string `form:"columns"`
+}
+
+// exportColumn is a field that can be picked for an export
+type exportColumn[T any] struct {
+	name  string
+	value func(T) any
+}
+
+// selectExportColumns returns the requested columns in the requested order, or
+// all of them when none are requested
+func selectExportColumns[T any](available []exportColumn[T], requested string) ([]exportColumn[T], error) {
+	if strings.TrimSpace(requested) == "" {
+		return available, nil
+	}
+
+	byName := make(map[string]exportColumn[T], len(available))
+	names := make([]string, 0, len(available))
+	for _, column := range available {
+		byName[column.name] = column
+		names = append(names, column.name)
+	}
+
+	var columns []exportColumn[T]
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown column %q, columns are %s", name, strings.Join(names, ", "))
+		}
+		seen[name] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("No columns selected")
+	}
+	return columns, nil
+}
+
+// streamExport writes the rows of query as they come off the database cursor, so
+// exports of any size use the same memory. Once the first byte is sent the status
+// can't change anymore, a failure after that cuts the download short.
+func streamExport[T any](c *gin.Context, query *gorm.DB, name string, format string, columns []exportColumn[T]) {
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + name})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	contentType := "text/csv; charset=utf-8"
+	if format == ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+
+	flush := func() {
+		csvWriter.Flush()
+		c.Writer.Flush()
+	}
+
+	if format == ExportFormatCSV {
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = column.name
+		}
+		csvWriter.Write(header)
+	}
+
+	count := 0
+	for rows.Next() {
+		var row T
+		if err := query.ScanRows(rows, &row); err != nil {
+			log.Printf("warning: %s export stopped: %v", name, err)
+			break
+		}
+
+		if format == ExportFormatCSV {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = exportCSVValue(column.value(row))
+			}
+			if err := csvWriter.Write(record); err != nil {
+				log.Printf("warning: %s export stopped: %v", name, err)
+				break
+			}
+		} else {
+			object := make(map[string]any, len(columns))
+			for _, column := range columns {
+				object[column.name] = column.value(row)
+			}
+			if err := encoder.Encode(object); err != nil {
+				log.Printf("warning: %s export stopped: %v", name, err)
+				break
+			}
+		}
+
+		// the client went away, no point reading the rest
+		if c.Request.Context().Err() != nil {
+			break
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("warning: %s export stopped: %v", name, err)
+	}
+
+	flush()
+}
+
+// exportCSVValue formats a column value for a spreadsheet cell
+func exportCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(v)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return csvText(*v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *uint:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return csvText(strings.Join(v, " "))
+	case models.ListingAttributes:
+		if len(v) == 0 {
+			return ""
+		}
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvText keeps user text from being run as a formula when the file is opened in
+// a spreadsheet, cells that would start one are prefixed with a quote
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"math"
+	"strings"
+)
+
+// upper bounds of the price buckets in the base currency, the last bucket is open-ended
+var priceBucketBounds = []float64{10, 25, 50, 100, 250, 500}
+
+const maxUniversityFacets = 20
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type PriceBucketCount struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+type SearchFacets struct {
+	Categories   []FacetCount       `json:"categories"`
+	PriceBuckets []PriceBucketCount `json:"price_buckets"`
+	Universities []FacetCount       `json:"universities"`
+	Status       []FacetCount       `json:"status"`
+	Conditions   []FacetCount       `json:"conditions"`
+}
+
+// loadSearchFacets counts the matches of the search per filter value, every
+// facet respects all the active filters
+func loadSearchFacets(params SearchParams) (*SearchFacets, error) {
+	facets := SearchFacets{
+		Categories:   []FacetCount{},
+		PriceBuckets: []PriceBucketCount{},
+		Universities: []FacetCount{},
+		Status:       []FacetCount{},
+		Conditions:   []FacetCount{},
+	}
+
+	// categories
+	if err := applySearchFilters(database.DB.Model(&models.Listing{}), params).
+		Select("listings.category AS value, COUNT(*) AS count").
+		Group("listings.category").
+		Order("count DESC").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	// price buckets, bounds are converted to the currency prices are shown in
+	var rate models.ExchangeRate
+	if err := database.DB.First(&rate, "currency = ?", params.currency()).Error; err != nil {
+		return nil, err
+	}
+	scale := math.Pow10(models.CurrencyMinorUnits(rate.Currency))
+	convert := func(bound float64) float64 {
+		return math.Round(bound*rate.Rate*scale) / scale
+	}
+
+	bounds := make([]string, len(priceBucketBounds))
+	for i, bound := range priceBucketBounds {
+		bounds[i] = fmt.Sprint(bound)
+	}
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := applySearchFilters(database.DB.Model(&models.Listing{}), params).
+		Select(fmt.Sprintf(
+			"width_bucket(listings.price_base, ARRAY[%s]::double precision[]) AS bucket, COUNT(*) AS count",
+			strings.Join(bounds, ","),
+		)).
+		Group("bucket").
+		Order("bucket").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+
+	for _, bucket := range buckets {
+		count := PriceBucketCount{Count: bucket.Count}
+		if bucket.Bucket > 0 {
+			count.Min = convert(priceBucketBounds[bucket.Bucket-1])
+		}
+		if bucket.Bucket < len(priceBucketBounds) {
+			max := convert(priceBucketBounds[bucket.Bucket])
+			count.Max = &max
+		}
+		facets.PriceBuckets = append(facets.PriceBuckets, count)
+	}
+
+	// seller universities
+	if err := applySearchFilters(database.DB.Model(&models.Listing{}), params).
+		Joins("JOIN users ON users.id = listings.user_id").
+		Where("COALESCE(users.university, '') <> ''").
+		Select("users.university AS value, COUNT(*) AS count").
+		Group("users.university").
+		Order("count DESC").
+		Limit(maxUniversityFacets).
+		Scan(&facets.Universities).Error; err != nil {
+		return nil, err
+	}
+
+	// listing status
+	if err := applySearchFilters(database.DB.Model(&models.Listing{}), params).
+		Select("listings.status AS value, COUNT(*) AS count").
+		Group("listings.status").
+		Order("count DESC").
+		Scan(&facets.Status).Error; err != nil {
+		return nil, err
+	}
+
+	// item condition, listings that don't state one are left out
+	if err := applySearchFilters(database.DB.Model(&models.Listing{}), params).
+		Where("listings.condition IS NOT NULL").
+		Select("listings.condition AS value, COUNT(*) AS count").
+		Group("listings.condition").
+		Order("count DESC").
+		Scan(&facets.Conditions).Error; err != nil {
+		return nil, err
+	}
+
+	return &facets, nil
+}
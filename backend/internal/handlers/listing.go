@@ -1,8 +1,8 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
@@ -11,18 +11,151 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
 )
 
 type CreateListingDTO struct {
 	Title           string                  `form:"title" binding:"required"`
 	Description     string                  `form:"description"`
 	Price           float64                 `form:"price"`
+	Currency        string                  `form:"currency"`
 	Images          []*multipart.FileHeader `form:"images[]"`
+	UploadIDs       []uint                  `form:"upload_ids[]"`
 	Category        string                  `form:"category"`
 	PriceSuggestion string                  `form:"price_suggestion"`
+	Status          string                  `form:"status"`
+	Attributes      string                  `form:"attributes"`
+	Condition       string                  `form:"condition"`
+	PickupLocation  *uint                   `form:"pickup_location_id"`
+}
+
+// maxListingImages is how many images a single listing can have
+const maxListingImages = 5
+
+var errCategoryAttributesUnavailable = errors.New("Failed to load category attributes")
+
+// listingInput is what a new listing is built from, whether it came from the
+// create form or from a row of a bulk import
+type listingInput struct {
+	Title          string
+	Description    string
+	Price          float64
+	Currency       string
+	Category       string
+	Attributes     string
+	Condition      string
+	PickupLocation *uint
+	Status         string
+	ImageCount     int
+}
+
+// newListing checks input against the rules every new listing follows and returns
+// the listing to create, not saved yet. The currency must already be resolved.
+func newListing(input listingInput, user models.User) (models.Listing, error) {
+	if strings.TrimSpace(input.Title) == "" {
+		return models.Listing{}, errors.New("Title cannot be empty")
+	}
+
+	if input.Price < 0 {
+		return models.Listing{}, errors.New("Price cannot be negative")
+	}
+
+	if input.ImageCount > maxListingImages {
+		return models.Listing{}, errors.New("Too many images")
+	}
+
+	category, err := findCategory(input.Category)
+	if err != nil {
+		return models.Listing{}, errors.New("Invalid category")
+	}
+
+	// attributes come as a JSON object and must follow the category schema
+	attributes, err := parseListingAttributes(input.Attributes)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	schema, err := categoryAttributes(category.Slug)
+	if err != nil {
+		return models.Listing{}, errCategoryAttributesUnavailable
+	}
+
+	attributes, err = validateListingAttributes(schema, attributes)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	condition, err := parseListingCondition(input.Condition)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	if input.PickupLocation != nil {
+		if _, err := findPickupLocation(*input.PickupLocation); err != nil {
+			return models.Listing{}, err
+		}
+	}
+
+	// new listings are published right away unless saved as a draft
+	status := models.ListingActive
+	switch models.ListingStatus(input.Status) {
+	case "", models.ListingActive:
+	case models.ListingDraft:
+		status = models.ListingDraft
+	default:
+		return models.Listing{}, errors.New("New listings can only be active or draft")
+	}
+
+	listing := models.Listing{
+		Title:            input.Title,
+		Description:      input.Description,
+		Category:         category.Slug,
+		Attributes:       attributes,
+		Condition:        condition,
+		PickupLocationID: input.PickupLocation,
+		Status:           status,
+		UserID:           user.ID,
+	}
+	if status == models.ListingActive {
+		now := time.Now()
+		expiresAt := now.Add(services.ListingExpiryPeriod(listing.Category))
+		listing.PublishedAt = &now
+		listing.ExpiresAt = &expiresAt
+	}
+	if err := services.SetListingPrice(database.DB, &listing, input.Price, input.Currency); err != nil {
+		return models.Listing{}, err
+	}
+
+	return listing, nil
+}
+
+// saveNewListing creates a listing built by newListing together with its images,
+// first price history entry and revision. extra, when given, runs in the same
+// transaction once the listing has its ID, the images it returns come after images.
+func saveNewListing(listing *models.Listing, images []models.ListingImage, user models.User, extra func(tx *gorm.DB) ([]models.ListingImage, error)) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(listing).Error; err != nil {
+			return err
+		}
+		if extra != nil {
+			more, err := extra(tx)
+			if err != nil {
+				return err
+			}
+			images = append(images, more...)
+		}
+		if err := services.SetListingImages(tx, listing, images); err != nil {
+			return err
+		}
+		if err := services.RecordListingPrice(tx, *listing); err != nil {
+			return err
+		}
+		return services.RecordListingRevision(tx, nil, *listing, models.ListingActorOwner, &user.ID)
+	})
 }
 
 func CreateListing(c *gin.Context) {
@@ -58,95 +191,77 @@ func CreateListing(c *gin.Context) {
 		}
 	}
 
-	// validate input data
-	if strings.TrimSpace(body.Title) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Title cannot be empty"})
-		return
-	}
-
-	if body.Price < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price cannot be negative"})
+	// prices are in the user's preferred currency unless another one is given
+	currency, err := viewerCurrency(c, body.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if len(body.Images) > 5 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many images"})
+	listing, err := newListing(listingInput{
+		Title:          body.Title,
+		Description:    body.Description,
+		Price:          body.Price,
+		Currency:       currency,
+		Category:       body.Category,
+		Attributes:     body.Attributes,
+		Condition:      body.Condition,
+		PickupLocation: body.PickupLocation,
+		Status:         body.Status,
+		ImageCount:     len(body.Images) + len(body.UploadIDs),
+	}, user)
+	if errors.Is(err, errCategoryAttributesUnavailable) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	switch models.Category(body.Category) {
-	case models.Electronics, models.Furniture, models.Books, models.Clothing, models.Services:
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// transaction
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	listing := models.Listing{
-		Title:       body.Title,
-		Description: body.Description,
-		Price:       body.Price,
-		Category:    models.Category(body.Category),
-		IsClosed:    false,
-		UserID:      user.ID,
-	}
-	if err := tx.Create(&listing).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create listing"})
+	// upload images to r2
+	uploaded, err := services.UploadImages(c.Request.Context(), body.Images, &user, "listings")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// price suggesiton might not be in the body
-	if body.PriceSuggestion != "" {
-		aiPriceReport := models.AIPriceReport{
-			SuggestedPriceMin: priceSuggestion.SuggestedPriceMin,
-			SuggestedPriceMax: priceSuggestion.SuggestedPriceMax,
-			Currency:          priceSuggestion.Currency,
-			ConfidenceLevel:   priceSuggestion.ConfidenceLevel,
-			Reasoning:         priceSuggestion.Reasoning,
-			ListingID:         listing.ID,
-		}
-
-		if err := tx.Create(&aiPriceReport).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create listing"})
-			return
+	// the price report and the uploads claimed for the listing are saved with it
+	err = saveNewListing(&listing, services.StoredListingImages(uploaded), user, func(tx *gorm.DB) ([]models.ListingImage, error) {
+		// price suggesiton might not be in the body
+		if priceSuggestion != nil {
+			aiPriceReport := models.AIPriceReport{
+				SuggestedPriceMin: priceSuggestion.SuggestedPriceMin,
+				SuggestedPriceMax: priceSuggestion.SuggestedPriceMax,
+				Currency:          priceSuggestion.Currency,
+				ConfidenceLevel:   priceSuggestion.ConfidenceLevel,
+				Reasoning:         priceSuggestion.Reasoning,
+				ListingID:         listing.ID,
+			}
+			if err := tx.Create(&aiPriceReport).Error; err != nil {
+				return nil, err
+			}
 		}
-	}
-
-	var imageURLs []string
-	if len(body.Images) > 0 {
-		var err error
 
-		// upload images to r2
-		imageURLs, err = services.UploadImages(c.Request.Context(), body.Images, &user, "listings")
+		// images uploaded straight to storage come after the ones in the form
+		claimed, err := services.ClaimUploads(tx, user.ID, body.UploadIDs, models.UploadPurposeListing)
 		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
-
-		listing.ImageURLs = imageURLs
-		if err := tx.Save(&listing).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing with images"})
+		return services.StoredListingImages(claimed), nil
+	})
+	if err != nil {
+		services.DeleteImages(c.Request.Context(), imageURLsOf(uploaded))
+		if errors.Is(err, services.ErrUploadRejected) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create listing"})
 		return
 	}
 
+	go notifySavedSearchMatches(listing)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"listing": listing,
@@ -171,25 +286,128 @@ func GetMyListings(c *gin.Context) {
 		return
 	}
 
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec, err := page.listingSort()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ?status=draft lists the drafts, which only ever show up here
+	base := database.DB.Where("user_id = ?", user.ID)
+	if status := c.Query("status"); status != "" {
+		if !models.ListingStatus(status).Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		base = base.Where("status = ?", status)
+	}
+
+	query, err := paginate(base, page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
 	var listings []models.Listing
-	if err := database.DB.Where("user_id = ?", user.ID).Find(&listings).Error; err != nil {
+	if err := query.Preload("Images", "is_cover = ?", true).Find(&listings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
 		return
 	}
 
+	listings, nextCursor := trimPage(listings, page, listingCursor(spec))
+
 	c.JSON(http.StatusOK, gin.H{
-		"listings": listings,
+		"listings":    listings,
+		"next_cursor": nextCursor,
 	})
 }
 
+// listingExportColumns are the columns ExportMyListings can write
+var listingExportColumns = []exportColumn[models.Listing]{
+	{"id", func(l models.Listing) any { return l.ID }},
+	{"title", func(l models.Listing) any { return l.Title }},
+	{"description", func(l models.Listing) any { return l.Description }},
+	{"category", func(l models.Listing) any { return l.Category }},
+	{"condition", func(l models.Listing) any { return conditionOrEmpty(l.Condition) }},
+	{"status", func(l models.Listing) any { return string(l.Status) }},
+	// rows are scanned straight off the cursor, so the price is derived here rather than by AfterFind
+	{"price", func(l models.Listing) any { return models.FromMinorUnits(l.PriceMinor, l.Currency) }},
+	{"currency", func(l models.Listing) any { return l.Currency }},
+	{"attributes", func(l models.Listing) any { return l.Attributes }},
+	{"pickup_location_id", func(l models.Listing) any { return l.PickupLocationID }},
+	{"image_urls", func(l models.Listing) any { return []string(l.ImageURLs) }},
+	{"wishlist_count", func(l models.Listing) any { return l.WishlistCount }},
+	{"created_at", func(l models.Listing) any { return l.CreatedAt }},
+	{"published_at", func(l models.Listing) any { return l.PublishedAt }},
+	{"expires_at", func(l models.Listing) any { return l.ExpiresAt }},
+	{"sold_at", func(l models.Listing) any { return l.SoldAt }},
+}
+
+func conditionOrEmpty(condition *models.ListingCondition) string {
+	if condition == nil {
+		return ""
+	}
+	return string(*condition)
+}
+
+// ExportMyListings streams all of the user's listings as CSV or NDJSON
+func ExportMyListings(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var params ExportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns, err := selectExportColumns(listingExportColumns, params.Columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Model(&models.Listing{}).
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC, id DESC")
+
+	streamExport(c, query, "listings", params.Format, columns)
+}
+
+// UpdateListingDTO changes only the fields that are set. An empty condition or
+// pickup_location_id 0 clears the field.
 type UpdateListingDTO struct {
-	Title       *string                 `form:"title"`
-	Description *string                 `form:"description"`
-	Price       *float64                `form:"price"`
-	Category    *string                 `form:"category"`
-	NewImages   []*multipart.FileHeader `form:"new_images"`
-	KeptImages  []string                `form:"kept_images"`
-	IsClosed    *bool                   `form:"is_closed"`
+	Title          *string                 `form:"title"`
+	Description    *string                 `form:"description"`
+	Price          *float64                `form:"price"`
+	Currency       *string                 `form:"currency"`
+	Category       *string                 `form:"category"`
+	NewImages      []*multipart.FileHeader `form:"new_images"`
+	NewUploads     []uint                  `form:"new_uploads"`
+	KeptImages     []string                `form:"kept_images"`
+	Status         *string                 `form:"status"`
+	Attributes     *string                 `form:"attributes"`
+	Condition      *string                 `form:"condition"`
+	PickupLocation *uint                   `form:"pickup_location_id"`
 }
 
 func UpdateListing(c *gin.Context) {
@@ -217,6 +435,11 @@ func UpdateListing(c *gin.Context) {
 		return
 	}
 
+	if listing.Status == models.ListingRemoved {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Listing was removed by a moderator"})
+		return
+	}
+
 	// binding body
 	var body UpdateListingDTO
 	if err := c.ShouldBindWith(&body, binding.FormMultipart); err != nil {
@@ -235,19 +458,104 @@ func UpdateListing(c *gin.Context) {
 		return
 	}
 
+	currency := listing.Currency
+	if body.Currency != nil {
+		var err error
+		currency, err = normalizeCurrency(*body.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var category models.Category
 	if body.Category != nil {
-		switch models.Category(*body.Category) {
-		case models.Electronics, models.Furniture, models.Books, models.Clothing, models.Services:
-		default:
+		var err error
+		category, err = findCategory(*body.Category)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
 			return
 		}
 	}
 
+	// attributes are checked against the schema of the category the listing ends up in.
+	// When only the category changes, values that don't belong to the new one are dropped.
+	var attributes models.ListingAttributes
+	if body.Attributes != nil || body.Category != nil {
+		categorySlug := listing.Category
+		if body.Category != nil {
+			categorySlug = category.Slug
+		}
+
+		schema, err := categoryAttributes(categorySlug)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load category attributes"})
+			return
+		}
+
+		if body.Attributes != nil {
+			attributes, err = parseListingAttributes(*body.Attributes)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			attributes = models.ListingAttributes{}
+			for _, attribute := range schema {
+				if value, ok := listing.Attributes[attribute.Key]; ok {
+					attributes[attribute.Key] = value
+				}
+			}
+		}
+
+		attributes, err = validateListingAttributes(schema, attributes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var condition *models.ListingCondition
+	if body.Condition != nil {
+		var err error
+		condition, err = parseListingCondition(*body.Condition)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if body.PickupLocation != nil && *body.PickupLocation != 0 && (listing.PickupLocationID == nil || *listing.PickupLocationID != *body.PickupLocation) {
+		if _, err := findPickupLocation(*body.PickupLocation); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// owners can publish, reserve, sell, archive and relist, the rest is up to moderators and expiry
+	var newStatus models.ListingStatus
+	if body.Status != nil && models.ListingStatus(*body.Status) != listing.Status {
+		newStatus = models.ListingStatus(*body.Status)
+
+		switch newStatus {
+		case models.ListingActive, models.ListingReserved, models.ListingSold, models.ListingArchived:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+
+		if !listing.Status.CanTransitionTo(newStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Cannot change status from %s to %s", listing.Status, newStatus),
+			})
+			return
+		}
+	}
+
 	// check if amount of images exceeds limit
-	if body.KeptImages != nil || body.NewImages != nil {
-		totalImages := len(body.KeptImages) + len(body.NewImages)
-		if totalImages > 5 {
+	if body.KeptImages != nil || body.NewImages != nil || body.NewUploads != nil {
+		totalImages := len(body.KeptImages) + len(body.NewImages) + len(body.NewUploads)
+		if totalImages > maxListingImages {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Total images cannot exceed 5"})
 			return
 		}
@@ -278,6 +586,9 @@ func UpdateListing(c *gin.Context) {
 		}
 	}()
 
+	before := models.SnapshotListing(listing)
+	previousPrice := services.ListingPriceOf(listing)
+
 	// assign input to entity
 	if body.Title != nil {
 		listing.Title = *body.Title
@@ -287,16 +598,36 @@ func UpdateListing(c *gin.Context) {
 		listing.Description = *body.Description
 	}
 
-	if body.Price != nil {
-		listing.Price = *body.Price
+	if body.Price != nil || body.Currency != nil {
+		price := listing.Price
+		if body.Price != nil {
+			price = *body.Price
+		}
+		if err := services.SetListingPrice(tx, &listing, price, currency); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	if body.Category != nil {
-		listing.Category = models.Category(*body.Category)
+		listing.Category = category.Slug
+	}
+
+	if attributes != nil {
+		listing.Attributes = attributes
+	}
+
+	if body.Condition != nil {
+		listing.Condition = condition
 	}
 
-	if body.IsClosed != nil {
-		listing.IsClosed = *body.IsClosed
+	if body.PickupLocation != nil {
+		listing.PickupLocationID = body.PickupLocation
+		if *body.PickupLocation == 0 {
+			listing.PickupLocationID = nil
+		}
+		listing.PickupLocation = nil
 	}
 
 	// save listing to db
@@ -306,7 +637,15 @@ func UpdateListing(c *gin.Context) {
 		return
 	}
 
-	if body.KeptImages != nil || body.NewImages != nil {
+	if newStatus != "" {
+		if err := services.TransitionListing(tx, &listing, newStatus, models.ListingActorOwner, &user.ID, ""); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing status"})
+			return
+		}
+	}
+
+	if body.KeptImages != nil || body.NewImages != nil || body.NewUploads != nil {
 
 		kept := make(map[string]bool)
 		for _, k := range body.KeptImages {
@@ -321,44 +660,73 @@ func UpdateListing(c *gin.Context) {
 			}
 		}
 
+		claimed, err := services.ClaimUploads(tx, user.ID, body.NewUploads, models.UploadPurposeListing)
+		if errors.Is(err, services.ErrUploadRejected) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing images"})
+			return
+		}
+
 		// upload new images
-		var newImageURLs []string
+		var uploaded []services.StoredImage
 		if len(body.NewImages) > 0 {
-			urls, err := services.UploadImages(c.Request.Context(), body.NewImages, &user, "listings")
+			var err error
+			uploaded, err = services.UploadImages(c.Request.Context(), body.NewImages, &user, "listings")
 			if err != nil {
 				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			newImageURLs = urls
 		}
 
-		// save images to db
-		listing.ImageURLs = append(body.KeptImages, newImageURLs...)
-		if err := tx.Save(&listing).Error; err != nil {
+		// save images to db, kept images keep their metadata and new ones follow them.
+		// This form has always made the first image the cover.
+		images := append(services.ListingImagesFromURLs(body.KeptImages), services.StoredListingImages(append(uploaded, claimed...))...)
+		if len(images) > 0 {
+			images[0].IsCover = true
+		}
+		if err := services.SetListingImages(tx, &listing, images); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing images"})
 			return
 		}
 
-		if len(imagesToDelete) > 0 {
-			go func() {
-				for _, imgURL := range imagesToDelete {
-					if err := services.DeleteImageByURL(context.Background(), imgURL); err != nil {
-						log.Printf("warning: failed to delete old image %s: %v", imgURL, err)
-					}
-				}
-			}()
+		// replaced images are deleted once the listing is saved
+		if err := services.QueueImageDeletes(tx, imagesToDelete); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing images"})
+			return
 		}
 
 	}
 
+	if err := services.RecordListingPrice(tx, listing); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing"})
+		return
+	}
+
+	if err := services.RecordListingRevision(tx, &before, listing, models.ListingActorOwner, &user.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing"})
+		return
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing"})
 		return
 	}
 
+	services.WakeStorageOutbox()
+	go notifySavedSearchMatches(listing)
+	go services.NotifyPriceDrop(listing, previousPrice)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"listing": listing,
@@ -390,21 +758,15 @@ func DeleteListing(c *gin.Context) {
 		return
 	}
 
-	// delete listing in db
-	if err := database.DB.Delete(&listing).Error; err != nil {
+	// move listing to the trash, images are purged once it can no longer be restored
+	if err := softDeleteListing(&listing, models.ListingActorOwner); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// delete listing images in storage
-	for _, imgURL := range listing.ImageURLs {
-		if err := services.DeleteImageByURL(c.Request.Context(), imgURL); err != nil {
-			fmt.Printf("warning: failed to delete image: %v\n", err)
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
+		"success":          true,
+		"restorable_until": listing.DeletedAt.Time.Add(services.ListingTrashRetention()),
 	})
 }
 
@@ -441,7 +803,19 @@ func ToggleWishlist(c *gin.Context) {
 
 	if err == nil {
 		// already exists - remove it
-		database.DB.Delete(&existingWishlist)
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&existingWishlist).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&listing).
+				UpdateColumn("wishlist_count", gorm.Expr("GREATEST(wishlist_count - 1, 0)")).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove from wishlist"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"action":  "removed",
@@ -450,25 +824,107 @@ func ToggleWishlist(c *gin.Context) {
 	}
 
 	// doesn't exist - add it
+	if !listing.Status.IsVisible() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Listing is not available"})
+		return
+	}
+
 	wishlistItem := models.WishlistListing{
 		UserID:    user.ID,
 		ListingID: listing.ID,
 	}
 
-	if err := database.DB.Create(&wishlistItem).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&wishlistItem).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&listing).
+			UpdateColumn("wishlist_count", gorm.Expr("wishlist_count + 1")).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add to wishlist"})
 		return
 	}
 
+	trackListingEvent(c, listing, models.ListingEventWishlistAdd)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"action":  "added",
 	})
 }
 
-func GetListingsFromWishlist(c *gin.Context) {
-	var listings []models.Listing
+type wishlistExportRow struct {
+	ListingID  uint
+	Title      string
+	Category   string
+	Status     models.ListingStatus
+	PriceMinor int64
+	Currency   string
+	SellerName string
+	AddedAt    time.Time
+}
+
+// wishlistExportColumns are the columns ExportWishlist can write
+var wishlistExportColumns = []exportColumn[wishlistExportRow]{
+	{"listing_id", func(r wishlistExportRow) any { return r.ListingID }},
+	{"title", func(r wishlistExportRow) any { return r.Title }},
+	{"category", func(r wishlistExportRow) any { return r.Category }},
+	{"status", func(r wishlistExportRow) any { return string(r.Status) }},
+	{"price", func(r wishlistExportRow) any { return models.FromMinorUnits(r.PriceMinor, r.Currency) }},
+	{"currency", func(r wishlistExportRow) any { return r.Currency }},
+	{"seller", func(r wishlistExportRow) any { return r.SellerName }},
+	{"added_at", func(r wishlistExportRow) any { return r.AddedAt }},
+}
+
+// ExportWishlist streams the user's wishlist as CSV or NDJSON
+func ExportWishlist(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var params ExportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns, err := selectExportColumns(wishlistExportColumns, params.Columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	query := database.DB.Table("wishlist_listings").
+		Select(`wishlist_listings.listing_id, listings.title, listings.category, listings.status,
+			listings.price_minor, listings.currency, users.name AS seller_name, wishlist_listings.created_at AS added_at`).
+		Joins("JOIN listings ON listings.id = wishlist_listings.listing_id AND listings.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = listings.user_id").
+		Where("wishlist_listings.user_id = ?", user.ID).
+		Order("wishlist_listings.created_at DESC, wishlist_listings.id DESC")
+
+	streamExport(c, query, "wishlist", params.Format, columns)
+}
+
+type wishlistedListing struct {
+	models.Listing
+	WishlistedAt time.Time `json:"-"`
+}
+
+func GetListingsFromWishlist(c *gin.Context) {
 	userAny, exists := c.Get("user")
 	if !exists {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -485,16 +941,55 @@ func GetListingsFromWishlist(c *gin.Context) {
 		return
 	}
 
-	if database.DB.
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec, err := page.listingSort()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// newest means most recently wishlisted here
+	if spec.name == SortNewest {
+		spec.column = "wishlist_listings.created_at"
+	}
+
+	query, err := paginate(database.DB.Table("listings").
+		Select("listings.*, wishlist_listings.created_at AS wishlisted_at").
 		Joins("JOIN wishlist_listings ON wishlist_listings.listing_id = listings.id").
 		Where("wishlist_listings.user_id = ?", user.ID).
-		Order("wishlist_listings.created_at DESC").
-		Find(&listings).Error != nil {
+		Where("listings.status IN ? AND listings.deleted_at IS NULL", models.ViewableListingStatuses), page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	var rows []wishlistedListing
+	if err := query.Find(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
 		return
 	}
 
-	c.JSON(http.StatusOK, listings)
+	rows, nextCursor := trimPage(rows, page, func(row wishlistedListing) string {
+		if spec.name == SortNewest {
+			return encodeCursor(spec, row.WishlistedAt, row.ID)
+		}
+		return listingCursor(spec)(row.Listing)
+	})
+
+	listings := make([]models.Listing, len(rows))
+	for i, row := range rows {
+		listings[i] = row.Listing
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"listings":    listings,
+		"next_cursor": nextCursor,
+	})
 }
 
 func CreateAIReport(c *gin.Context) {
@@ -543,8 +1038,28 @@ func CreateAIReport(c *gin.Context) {
 		return
 	}
 
+	// the stated condition falls back to the one saved on the listing
+	condition, err := parseListingCondition(body.Condition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if condition == nil {
+		condition = listing.Condition
+	}
+
+	// the report is in the listing's currency so the two can be compared
+	currency := listing.Currency
+	if body.Currency != "" {
+		currency, err = normalizeCurrency(body.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// get ai report
-	priceSuggestionResponse, err := services.SuggestPrice(ctx, body.Title, body.Language, body.Description, body.Images, body.ImageUrls)
+	priceSuggestionResponse, err := services.SuggestPrice(ctx, body.Title, body.Language, body.Description, conditionOrUnknown(condition), currency, body.Images, body.ImageUrls)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	dayLayout            = "2006-01-02"
+)
+
+type AnalyticsParams struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365"`
+}
+
+type DailyStatsPoint struct {
+	Day           string `json:"day"`
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"unique_viewers"`
+	WishlistAdds  int64  `json:"wishlist_adds"`
+	ContactClicks int64  `json:"contact_clicks"`
+}
+
+// StatsTotals sums a range of daily stats. ViewerDays adds up each day's distinct
+// viewers, someone who views the listing on three days counts three times.
+type StatsTotals struct {
+	Views         int64   `json:"views"`
+	ViewerDays    int64   `json:"viewer_days"`
+	WishlistAdds  int64   `json:"wishlist_adds"`
+	ContactClicks int64   `json:"contact_clicks"`
+	WishlistRate  float64 `json:"wishlist_rate"`
+	ContactRate   float64 `json:"contact_rate"`
+}
+
+type ListingAnalyticsResponse struct {
+	ListingID uint              `json:"listing_id"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Totals    StatsTotals       `json:"totals"`
+	Series    []DailyStatsPoint `json:"series"`
+	// CategoryAverage is what a visible listing in the same category got over the same days
+	CategoryAverage StatsTotals `json:"category_average"`
+}
+
+// listingViewerKey identifies a viewer for deduplication: signed in users by ID,
+// everyone else by a hash of IP and user agent so no raw IPs are stored
+func listingViewerKey(c *gin.Context) string {
+	if userAny, exists := c.Get("user"); exists {
+		if user, ok := userAny.(models.User); ok {
+			return fmt.Sprintf("user:%d", user.ID)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// trackListingEvent records an event in the background, bots and the owner are ignored
+func trackListingEvent(c *gin.Context, listing models.Listing, eventType models.ListingEventType) {
+	if services.IsBot(c.Request.UserAgent()) {
+		return
+	}
+
+	if userAny, exists := c.Get("user"); exists {
+		if user, ok := userAny.(models.User); ok && user.ID == listing.UserID {
+			return
+		}
+	}
+
+	viewerKey := listingViewerKey(c)
+	go func() {
+		if err := services.TrackListingEvent(listing.ID, eventType, viewerKey); err != nil {
+			log.Printf("warning: %v", err)
+		}
+	}()
+}
+
+// analyticsRange returns the first and last day covered, both inclusive
+func analyticsRange(days int) (time.Time, time.Time) {
+	if days <= 0 {
+		days = defaultAnalyticsDays
+	}
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return to.AddDate(0, 0, -(days - 1)), to
+}
+
+// loadStatsSeries sums the daily stats of the listings matched by listings (a
+// subquery selecting listing ids) and fills days without activity with zeros
+func loadStatsSeries(listings *gorm.DB, from time.Time, to time.Time) ([]DailyStatsPoint, StatsTotals, error) {
+	var rows []struct {
+		Day           time.Time
+		Views         int64
+		UniqueViewers int64
+		WishlistAdds  int64
+		ContactClicks int64
+	}
+	if err := database.DB.Model(&models.ListingDailyStats{}).
+		Select("day, SUM(views) AS views, SUM(unique_viewers) AS unique_viewers, SUM(wishlist_adds) AS wishlist_adds, SUM(contact_clicks) AS contact_clicks").
+		Where("listing_id IN (?) AND day BETWEEN ? AND ?", listings, from, to).
+		Group("day").
+		Scan(&rows).Error; err != nil {
+		return nil, StatsTotals{}, err
+	}
+
+	byDay := make(map[string]DailyStatsPoint, len(rows))
+	for _, row := range rows {
+		day := row.Day.Format(dayLayout)
+		byDay[day] = DailyStatsPoint{
+			Day:           day,
+			Views:         row.Views,
+			UniqueViewers: row.UniqueViewers,
+			WishlistAdds:  row.WishlistAdds,
+			ContactClicks: row.ContactClicks,
+		}
+	}
+
+	series := []DailyStatsPoint{}
+	var totals StatsTotals
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		point, ok := byDay[day.Format(dayLayout)]
+		if !ok {
+			point = DailyStatsPoint{Day: day.Format(dayLayout)}
+		}
+		series = append(series, point)
+
+		totals.Views += point.Views
+		totals.ViewerDays += point.UniqueViewers
+		totals.WishlistAdds += point.WishlistAdds
+		totals.ContactClicks += point.ContactClicks
+	}
+	totals.computeRates()
+
+	return series, totals, nil
+}
+
+// computeRates sets the share of views that led to a wishlist add or a contact click
+func (t *StatsTotals) computeRates() {
+	if t.Views == 0 {
+		return
+	}
+	t.WishlistRate = float64(t.WishlistAdds) / float64(t.Views)
+	t.ContactRate = float64(t.ContactClicks) / float64(t.Views)
+}
+
+// RecordContactClick counts a click on the seller's contact details of a listing
+func RecordContactClick(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ?", listingID).Error; err != nil || !listing.Status.IsViewable() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	trackListingEvent(c, listing, models.ListingEventContactClick)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// GetListingAnalytics returns daily views, wishlist adds and contact clicks of one
+// of the user's listings, next to what listings in the same category get on average
+func GetListingAnalytics(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var params AnalyticsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to := analyticsRange(params.Days)
+
+	series, totals, err := loadStatsSeries(database.DB.Model(&models.Listing{}).Select("id").Where("id = ?", listing.ID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+		return
+	}
+
+	// the category benchmark averages over the visible listings of the category, this one excluded
+	var categoryTotals struct {
+		Listings      int64
+		Views         int64
+		ViewerDays    int64
+		WishlistAdds  int64
+		ContactClicks int64
+	}
+	if err := database.DB.Raw(`
+		SELECT
+			COUNT(*) AS listings,
+			COALESCE(SUM(stats.views), 0) AS views,
+			COALESCE(SUM(stats.unique_viewers), 0) AS viewer_days,
+			COALESCE(SUM(stats.wishlist_adds), 0) AS wishlist_adds,
+			COALESCE(SUM(stats.contact_clicks), 0) AS contact_clicks
+		FROM listings
+		LEFT JOIN (
+			SELECT listing_id, SUM(views) AS views, SUM(unique_viewers) AS unique_viewers,
+				SUM(wishlist_adds) AS wishlist_adds, SUM(contact_clicks) AS contact_clicks
+			FROM listing_daily_stats
+			WHERE day BETWEEN ? AND ?
+			GROUP BY listing_id
+		) stats ON stats.listing_id = listings.id
+		WHERE listings.category = ? AND listings.id <> ? AND listings.status IN ? AND listings.deleted_at IS NULL`,
+		from, to, listing.Category, listing.ID, models.VisibleListingStatuses,
+	).Scan(&categoryTotals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+		return
+	}
+
+	var categoryAverage StatsTotals
+	if categoryTotals.Listings > 0 {
+		categoryAverage = StatsTotals{
+			Views:         categoryTotals.Views / categoryTotals.Listings,
+			ViewerDays:    categoryTotals.ViewerDays / categoryTotals.Listings,
+			WishlistAdds:  categoryTotals.WishlistAdds / categoryTotals.Listings,
+			ContactClicks: categoryTotals.ContactClicks / categoryTotals.Listings,
+		}
+		categoryAverage.computeRates()
+	}
+
+	c.JSON(http.StatusOK, ListingAnalyticsResponse{
+		ListingID:       listing.ID,
+		From:            from.Format(dayLayout),
+		To:              to.Format(dayLayout),
+		Totals:          totals,
+		Series:          series,
+		CategoryAverage: categoryAverage,
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxImageAltTextLength = 300
+
+type ListingImageEditDTO struct {
+	ID      uint    `json:"id" binding:"required"`
+	AltText *string `json:"alt_text"`
+}
+
+// UpdateListingImagesDTO lists every image of the listing in its new order. Alt
+// text is only changed where given, the cover only when cover_id is set.
+type UpdateListingImagesDTO struct {
+	Images  []ListingImageEditDTO `json:"images" binding:"required,dive"`
+	CoverID *uint                 `json:"cover_id"`
+}
+
+// GetListingImages returns a listing's images in order with their metadata
+func GetListingImages(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var images []models.ListingImage
+	if err := database.DB.Where("listing_id = ?", listing.ID).Order("position").Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// UpdateListingImages reorders a listing's images, picks the cover and edits alt
+// text without uploading anything again. Adding and removing images stays with
+// UpdateListing.
+func UpdateListingImages(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body UpdateListingImagesDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var current []models.ListingImage
+	if err := database.DB.Where("listing_id = ?", listing.ID).Find(&current).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+		return
+	}
+
+	byID := make(map[uint]models.ListingImage, len(current))
+	for _, image := range current {
+		byID[image.ID] = image
+	}
+
+	// the new order has to name every image exactly once
+	if len(body.Images) != len(current) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Images must list every image of the listing exactly once"})
+		return
+	}
+
+	seen := make(map[uint]bool, len(body.Images))
+	ordered := make([]models.ListingImage, 0, len(body.Images))
+	altTexts := make(map[uint]string)
+	for _, edit := range body.Images {
+		image, ok := byID[edit.ID]
+		if !ok || seen[edit.ID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Images must list every image of the listing exactly once"})
+			return
+		}
+		seen[edit.ID] = true
+
+		if edit.AltText != nil {
+			altText := strings.TrimSpace(*edit.AltText)
+			if utf8.RuneCountInString(altText) > maxImageAltTextLength {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Alt text is too long"})
+				return
+			}
+			altTexts[edit.ID] = altText
+		}
+
+		// SetListingImages keeps the current cover unless one is flagged
+		image.IsCover = body.CoverID != nil && *body.CoverID == edit.ID
+		ordered = append(ordered, image)
+	}
+
+	if body.CoverID != nil && !seen[*body.CoverID] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cover must be one of the listing's images"})
+		return
+	}
+
+	before := models.SnapshotListing(listing)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.SetListingImages(tx, &listing, ordered); err != nil {
+			return err
+		}
+
+		for id, altText := range altTexts {
+			if err := tx.Model(&models.ListingImage{}).Where("id = ?", id).Update("alt_text", altText).Error; err != nil {
+				return err
+			}
+		}
+
+		return services.RecordListingRevision(tx, &before, listing, models.ListingActorOwner, &user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update images"})
+		return
+	}
+
+	for i, image := range listing.Images {
+		if altText, ok := altTexts[image.ID]; ok {
+			listing.Images[i].AltText = altText
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"images":  listing.Images,
+	})
+}
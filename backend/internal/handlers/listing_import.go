@@ -0,0 +1,542 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxImportCSVSize     = 1 << 20
+	maxImportArchiveSize = 100 << 20
+	maxImportImageSize   = 10 << 20
+	maxImportRows        = 200
+	// separates the image filenames of a row
+	importImageSeparator = ";"
+)
+
+// importColumns are the CSV columns an import understands, title, price and
+// category are required. The other columns are optional and can be left out.
+var importColumns = []string{
+	"title", "description", "price", "currency", "category", "images",
+	"condition", "attributes", "pickup_location_id", "status",
+}
+
+var requiredImportColumns = []string{"title", "price", "category"}
+
+// importRow is one CSV row by column name. Line is where the row starts in the
+// file so errors point at what the seller sees in their spreadsheet.
+type importRow struct {
+	Line   int
+	Values map[string]string
+	Error  string
+}
+
+// ImportListings creates listings in bulk from a CSV file and an optional ZIP of
+// the images the rows name. The file is checked right away, rows are processed
+// in the background and their results can be followed with GetListingImport.
+func ImportListings(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	// only one import at a time, checked here to skip reading the files and
+	// enforced by a unique index when the import is created
+	var inProgress int64
+	if err := database.DB.Model(&models.ListingImport{}).
+		Where("user_id = ? AND status IN ?", user.ID,
+			[]models.ListingImportStatus{models.ListingImportPending, models.ListingImportProcessing}).
+		Count(&inProgress).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+	if inProgress > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An import is already running"})
+		return
+	}
+
+	csvHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
+		return
+	}
+
+	if csvHeader.Size > maxImportCSVSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is too large"})
+		return
+	}
+
+	csvFile, err := csvHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CSV file"})
+		return
+	}
+	defer csvFile.Close()
+
+	rows, err := parseImportCSV(csvFile)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// rows without a currency are priced in the user's preferred one
+	currency, err := viewerCurrency(c, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the uploaded archive is gone once the request ends, the import works on a copy
+	var archivePath string
+	if archiveHeader, err := c.FormFile("images"); err == nil {
+		archivePath, err = copyImportArchive(archiveHeader)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	listingImport := models.ListingImport{
+		UserID:    user.ID,
+		Status:    models.ListingImportPending,
+		TotalRows: len(rows),
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&listingImport)
+	if result.Error != nil || result.RowsAffected == 0 {
+		if archivePath != "" {
+			os.Remove(archivePath)
+		}
+		if result.Error == nil {
+			// another request started an import in the meantime
+			c.JSON(http.StatusConflict, gin.H{"error": "An import is already running"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	go runListingImport(context.Background(), listingImport.ID, user, rows, currency, archivePath)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"import":  listingImport,
+	})
+}
+
+// GetListingImports lists the user's imports, newest first
+func GetListingImports(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var imports []models.ListingImport
+	if err := database.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&imports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, imports)
+}
+
+// GetListingImport returns an import's progress and the result of every processed row
+func GetListingImport(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	importID := c.Param("id")
+	var listingImport models.ListingImport
+	if err := database.DB.
+		Preload("Rows", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line")
+		}).
+		First(&listingImport, "id = ? AND user_id = ?", importID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, listingImport)
+}
+
+// parseImportCSV reads the header and every row. A malformed file fails as a
+// whole, a row with the wrong number of fields only fails that row.
+func parseImportCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		// spreadsheets often save UTF-8 with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		if !isImportColumn(name) {
+			return nil, fmt.Errorf("Unknown column %q, columns are %s", name, strings.Join(importColumns, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("Column %q appears twice", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	for _, name := range requiredImportColumns {
+		if !seen[name] {
+			return nil, fmt.Errorf("Column %q is required", name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		// blank lines between rows are skipped
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("An import can have at most %d rows", maxImportRows)
+		}
+
+		row := importRow{Line: line, Values: make(map[string]string, len(columns))}
+		if len(record) != len(columns) {
+			row.Error = fmt.Sprintf("Expected %d fields, got %d", len(columns), len(record))
+		}
+		for i, value := range record {
+			if i < len(columns) {
+				row.Values[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("CSV file has no listings")
+	}
+
+	return rows, nil
+}
+
+func isImportColumn(name string) bool {
+	for _, column := range importColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// copyImportArchive checks the uploaded ZIP and copies it to a temp file the
+// background import can read after the request is done
+func copyImportArchive(fileHeader *multipart.FileHeader) (string, error) {
+	if fileHeader.Size > maxImportArchiveSize {
+		return "", errors.New("Image archive is too large")
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", errors.New("Failed to read image archive")
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "listing-import-*.zip")
+	if err != nil {
+		return "", errors.New("Failed to store image archive")
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", errors.New("Failed to store image archive")
+	}
+
+	archive, err := zip.OpenReader(dst.Name())
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", errors.New("Image archive must be a ZIP file")
+	}
+	archive.Close()
+
+	return dst.Name(), nil
+}
+
+// runListingImport creates a listing for every row, one at a time so a failing
+// row doesn't affect the others. Meant to run in the background.
+func runListingImport(ctx context.Context, importID uint, user models.User, rows []importRow, currency string, archivePath string) {
+	if archivePath != "" {
+		defer os.Remove(archivePath)
+	}
+
+	var listingImport models.ListingImport
+	if err := database.DB.First(&listingImport, importID).Error; err != nil {
+		log.Printf("listing import %d: %v", importID, err)
+		return
+	}
+
+	database.DB.Model(&listingImport).Update("status", models.ListingImportProcessing)
+
+	// images are matched to rows by file name, folders inside the archive don't matter
+	images := make(map[string]*zip.File)
+	if archivePath != "" {
+		archive, err := zip.OpenReader(archivePath)
+		if err != nil {
+			log.Printf("listing import %d failed: %v", importID, err)
+			database.DB.Model(&listingImport).Updates(map[string]interface{}{
+				"status": models.ListingImportFailed,
+				"error":  "image archive could not be opened",
+			})
+			return
+		}
+		defer archive.Close()
+
+		for _, file := range archive.File {
+			if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+				continue
+			}
+			images[path.Base(file.Name)] = file
+		}
+	}
+
+	succeeded, failed := 0, 0
+	for _, row := range rows {
+		result := models.ListingImportRow{
+			ImportID: importID,
+			Line:     row.Line,
+			Title:    row.Values["title"],
+		}
+
+		listing, err := importListingRow(ctx, row, user, currency, images)
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.ListingID = &listing.ID
+			succeeded++
+			go notifySavedSearchMatches(listing)
+		}
+
+		if err := database.DB.Create(&result).Error; err != nil {
+			log.Printf("warning: listing import %d: failed to save row %d: %v", importID, row.Line, err)
+		}
+		database.DB.Model(&listingImport).Updates(map[string]interface{}{
+			"succeeded_rows": succeeded,
+			"failed_rows":    failed,
+		})
+	}
+
+	now := time.Now()
+	database.DB.Model(&listingImport).Updates(map[string]interface{}{
+		"status":       models.ListingImportCompleted,
+		"completed_at": now,
+	})
+
+	notification := models.Notification{
+		UserID: user.ID,
+		Type:   models.NotificationListingImport,
+		Title:  "Your listing import has finished",
+		Body:   fmt.Sprintf("%d of %d listings were created, %d rows failed.", succeeded, len(rows), failed),
+	}
+	if err := services.Notify(notification, false); err != nil {
+		log.Printf("warning: %v", err)
+	}
+}
+
+// importListingRow validates a row with the same rules as CreateListing and creates
+// the listing with its images. Nothing is left behind when the row fails.
+func importListingRow(ctx context.Context, row importRow, user models.User, currency string, images map[string]*zip.File) (models.Listing, error) {
+	if row.Error != "" {
+		return models.Listing{}, errors.New(row.Error)
+	}
+
+	var price float64
+	if value := row.Values["price"]; value != "" {
+		var err error
+		price, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return models.Listing{}, errors.New("Invalid price")
+		}
+	}
+
+	if value := row.Values["currency"]; value != "" {
+		var err error
+		currency, err = normalizeCurrency(value)
+		if err != nil {
+			return models.Listing{}, err
+		}
+	}
+
+	var pickupLocation *uint
+	if value := row.Values["pickup_location_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return models.Listing{}, errors.New("Invalid pickup location")
+		}
+		locationID := uint(id)
+		pickupLocation = &locationID
+	}
+
+	var filenames []string
+	for _, name := range strings.Split(row.Values["images"], importImageSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			filenames = append(filenames, name)
+		}
+	}
+
+	listing, err := newListing(listingInput{
+		Title:          row.Values["title"],
+		Description:    row.Values["description"],
+		Price:          price,
+		Currency:       currency,
+		Category:       row.Values["category"],
+		Attributes:     row.Values["attributes"],
+		Condition:      row.Values["condition"],
+		PickupLocation: pickupLocation,
+		Status:         row.Values["status"],
+		ImageCount:     len(filenames),
+	}, user)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	// every image must be there before anything is uploaded
+	files := make([]*zip.File, 0, len(filenames))
+	for _, name := range filenames {
+		file, ok := images[path.Base(name)]
+		if !ok {
+			return models.Listing{}, fmt.Errorf("Image %s is not in the archive", name)
+		}
+		if file.UncompressedSize64 > maxImportImageSize {
+			return models.Listing{}, fmt.Errorf("Image %s is too large", name)
+		}
+		files = append(files, file)
+	}
+
+	uploaded, err := uploadImportImages(ctx, files, &user)
+	if err != nil {
+		return models.Listing{}, err
+	}
+
+	if err := saveNewListing(&listing, services.StoredListingImages(uploaded), user, nil); err != nil {
+		services.DeleteImages(ctx, imageURLsOf(uploaded))
+		log.Printf("warning: listing import: failed to create listing: %v", err)
+		return models.Listing{}, errors.New("Failed to create listing")
+	}
+
+	return listing, nil
+}
+
+// uploadImportImages uploads the archive entries, removing the ones already
+// uploaded if one of them fails
+func uploadImportImages(ctx context.Context, files []*zip.File, user *models.User) ([]services.StoredImage, error) {
+	var uploaded []services.StoredImage
+
+	for _, file := range files {
+		stored, err := uploadImportImage(ctx, file, user)
+		if err != nil {
+			services.DeleteImages(ctx, imageURLsOf(uploaded))
+			return nil, fmt.Errorf("Failed to upload %s: %v", path.Base(file.Name), err)
+		}
+		uploaded = append(uploaded, stored)
+	}
+
+	return uploaded, nil
+}
+
+func uploadImportImage(ctx context.Context, file *zip.File, user *models.User) (services.StoredImage, error) {
+	src, err := file.Open()
+	if err != nil {
+		return services.StoredImage{}, err
+	}
+	defer src.Close()
+
+	// the size in the archive header can't be trusted, so the read is capped too
+	data, err := io.ReadAll(io.LimitReader(src, maxImportImageSize+1))
+	if err != nil {
+		return services.StoredImage{}, err
+	}
+	if len(data) > maxImportImageSize {
+		return services.StoredImage{}, errors.New("image is too large")
+	}
+
+	return services.UploadImageReader(ctx, bytes.NewReader(data), user, "listings")
+}
+
+func imageURLsOf(images []services.StoredImage) []string {
+	urls := make([]string, len(images))
+	for i, image := range images {
+		urls[i] = image.URL
+	}
+	return urls
+}
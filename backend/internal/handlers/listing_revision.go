@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RevisionDiffParams struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"omitempty,min=1"`
+}
+
+type RevisionDiffResponse struct {
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes models.ListingChanges `json:"changes"`
+}
+
+// GetListingRevisions lists every revision of the user's listing, newest first
+func GetListingRevisions(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	respondListingRevisions(c, listing.ID)
+}
+
+// GetListingRevisionDiff compares two revisions of the user's listing
+func GetListingRevisionDiff(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	respondListingRevisionDiff(c, listing.ID)
+}
+
+// AdminGetListingRevisions lists every revision of any listing, including deleted ones
+func AdminGetListingRevisions(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.Unscoped().First(&listing, "id = ?", listingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	respondListingRevisions(c, listing.ID)
+}
+
+// AdminGetListingRevisionDiff compares two revisions of any listing
+func AdminGetListingRevisionDiff(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.Unscoped().First(&listing, "id = ?", listingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	respondListingRevisionDiff(c, listing.ID)
+}
+
+// AdminRevertListing restores the text fields, price, attributes, condition and pickup location
+// of a listing to an earlier revision. Images replaced in later edits are already deleted from
+// storage, so they are left as they are.
+func AdminRevertListing(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ?", listingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var revision models.ListingRevision
+	if err := database.DB.First(&revision, "listing_id = ? AND revision = ?", listing.ID, c.Param("revision")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	// the category may have been deleted since the revision was recorded
+	category, err := findCategory(revision.Snapshot.Category)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The revision's category no longer exists"})
+		return
+	}
+
+	before := models.SnapshotListing(listing)
+	previousPrice := services.ListingPriceOf(listing)
+	listing.Title = revision.Snapshot.Title
+	listing.Description = revision.Snapshot.Description
+	listing.Category = category.Slug
+	listing.Attributes = revision.Snapshot.Attributes
+	if listing.Attributes == nil {
+		listing.Attributes = models.ListingAttributes{}
+	}
+	listing.Condition = nil
+	if revision.Snapshot.Condition != "" {
+		condition := models.ListingCondition(revision.Snapshot.Condition)
+		listing.Condition = &condition
+	}
+	listing.PickupLocationID = revision.Snapshot.PickupLocationID
+
+	// revisions recorded before prices had a currency were in the base currency
+	currency := revision.Snapshot.Currency
+	if currency == "" {
+		currency = models.BaseCurrency
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.SetListingPrice(tx, &listing, revision.Snapshot.Price, currency); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&listing).Error; err != nil {
+			return err
+		}
+
+		if err := services.RecordListingPrice(tx, listing); err != nil {
+			return err
+		}
+
+		return services.RecordListingRevision(tx, &before, listing, models.ListingActorAdmin, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert listing"})
+		return
+	}
+
+	go services.NotifyPriceDrop(listing, previousPrice)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
+
+func respondListingRevisions(c *gin.Context, listingID uint) {
+	var revisions []models.ListingRevision
+	if err := database.DB.Where("listing_id = ?", listingID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// respondListingRevisionDiff compares revision "from" with revision "to", or with the latest one
+func respondListingRevisionDiff(c *gin.Context, listingID uint) {
+	var params RevisionDiffParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var from models.ListingRevision
+	if err := database.DB.First(&from, "listing_id = ? AND revision = ?", listingID, params.From).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	var to models.ListingRevision
+	query := database.DB.Where("listing_id = ?", listingID)
+	if params.To != 0 {
+		query = query.Where("revision = ?", params.To)
+	} else {
+		query = query.Order("revision DESC")
+	}
+	if err := query.First(&to).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RevisionDiffResponse{
+		From:    from.Revision,
+		To:      to.Revision,
+		Changes: models.DiffListingSnapshots(from.Snapshot, to.Snapshot),
+	})
+}
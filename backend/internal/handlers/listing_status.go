@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminListingStatusDTO struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// GetListingStatusHistory returns every status change of the user's listing, oldest first
+func GetListingStatusHistory(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var transitions []models.ListingStatusTransition
+	if err := database.DB.Where("listing_id = ?", listing.ID).Order("created_at, id").Find(&transitions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      listing.Status,
+		"transitions": transitions,
+	})
+}
+
+// RenewListing restarts the expiry period of an active listing or republishes an expired one
+func RenewListing(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	if listing.Status != models.ListingActive && listing.Status != models.ListingExpired {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only active or expired listings can be renewed"})
+		return
+	}
+
+	wasExpired := listing.Status == models.ListingExpired
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.RenewListing(tx, &listing, &user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to renew listing"})
+		return
+	}
+
+	// a republished listing is new to saved searches that haven't seen it
+	if wasExpired {
+		go notifySavedSearchMatches(listing)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
+
+// AdminUpdateListingStatus lets moderators remove a listing or restore it
+func AdminUpdateListingStatus(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ?", listingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	var body AdminListingStatusDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := models.ListingStatus(body.Status)
+	if !status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	if status == models.ListingRemoved && strings.TrimSpace(body.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to remove a listing"})
+		return
+	}
+
+	if !listing.Status.CanTransitionTo(status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Cannot change status from %s to %s", listing.Status, status),
+		})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.TransitionListing(tx, &listing, status, models.ListingActorAdmin, nil, body.Reason)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update listing status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
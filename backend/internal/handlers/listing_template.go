@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateListingTemplateDTO describes a template from scratch, the fields follow
+// the same rules as a new listing
+type CreateListingTemplateDTO struct {
+	Name           string          `json:"name" binding:"required"`
+	Title          string          `json:"title" binding:"required"`
+	Description    string          `json:"description"`
+	Price          float64         `json:"price"`
+	Currency       string          `json:"currency"`
+	Category       string          `json:"category" binding:"required"`
+	Attributes     json.RawMessage `json:"attributes"`
+	Condition      string          `json:"condition"`
+	PickupLocation *uint           `json:"pickup_location_id"`
+}
+
+type SaveListingTemplateDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// NewListingFromDTO picks the status of a listing made from a template or a clone,
+// they start as drafts unless published right away
+type NewListingFromDTO struct {
+	Status string `json:"status"`
+}
+
+func attributesJSON(attributes models.ListingAttributes) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(attributes)
+	return string(encoded)
+}
+
+// templateFromListing copies what a template keeps from a listing, images are
+// copied separately
+func templateFromListing(name string, listing models.Listing) models.ListingTemplate {
+	return models.ListingTemplate{
+		UserID:           listing.UserID,
+		Name:             strings.TrimSpace(name),
+		Title:            listing.Title,
+		Description:      listing.Description,
+		PriceMinor:       listing.PriceMinor,
+		Currency:         listing.Currency,
+		Price:            listing.Price,
+		Category:         listing.Category,
+		Attributes:       listing.Attributes,
+		Condition:        listing.Condition,
+		PickupLocationID: listing.PickupLocationID,
+	}
+}
+
+// copyListingFrom builds a new listing from input and gives it copies of images,
+// so deleting the source never takes the new listing's images with it. The copies
+// keep the order, cover, alt text and metadata of the originals.
+func copyListingFrom(ctx context.Context, input listingInput, images []models.ListingImage, user models.User) (models.Listing, int, error) {
+	input.ImageCount = len(images)
+	if input.Status == "" {
+		input.Status = string(models.ListingDraft)
+	}
+
+	listing, err := newListing(input, user)
+	if errors.Is(err, errCategoryAttributesUnavailable) {
+		return listing, http.StatusInternalServerError, err
+	}
+	if err != nil {
+		return listing, http.StatusBadRequest, err
+	}
+
+	urls := make([]string, len(images))
+	for i, image := range images {
+		urls[i] = image.URL
+	}
+
+	copied, err := services.CopyImages(ctx, urls, &user, "listings")
+	if err != nil {
+		log.Printf("warning: %v", err)
+		return listing, http.StatusInternalServerError, errors.New("Failed to copy images")
+	}
+
+	copies := make([]models.ListingImage, len(images))
+	for i, image := range images {
+		image.URL = copied[i]
+		// the variants were copied along with the image
+		if image.CardURL != nil && *image.CardURL != "" {
+			cardURL := services.ImageVariantURL(copied[i], services.ImageVariantCard)
+			thumbURL := services.ImageVariantURL(copied[i], services.ImageVariantThumb)
+			image.CardURL = &cardURL
+			image.ThumbURL = &thumbURL
+		}
+		copies[i] = image
+	}
+
+	if err := saveNewListing(&listing, copies, user, nil); err != nil {
+		services.DeleteImages(ctx, copied)
+		return listing, http.StatusInternalServerError, errors.New("Failed to create listing")
+	}
+
+	go notifySavedSearchMatches(listing)
+
+	return listing, http.StatusCreated, nil
+}
+
+// CloneListing creates a new listing from one of the user's listings, as a draft
+// unless another status is requested
+func CloneListing(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body NewListingFromDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	listingID := c.Param("id")
+	var source models.Listing
+	if err := database.DB.
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		First(&source, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	listing, status, err := copyListingFrom(c.Request.Context(), listingInput{
+		Title:          source.Title,
+		Description:    source.Description,
+		Price:          source.Price,
+		Currency:       source.Currency,
+		Category:       source.Category,
+		Attributes:     attributesJSON(source.Attributes),
+		Condition:      conditionOrEmpty(source.Condition),
+		PickupLocation: source.PickupLocationID,
+		Status:         body.Status,
+	}, source.Images, user)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
+
+// GetListingTemplates lists the user's templates by name
+func GetListingTemplates(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var templates []models.ListingTemplate
+	if err := database.DB.Where("user_id = ?", user.ID).Order("name, id").Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateListingTemplate saves a template from scratch, without images
+func CreateListingTemplate(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body CreateListingTemplateDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+
+	currency, err := viewerCurrency(c, body.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// a template holds what a listing would, so it's checked like one
+	listing, err := newListing(listingInput{
+		Title:          body.Title,
+		Description:    body.Description,
+		Price:          body.Price,
+		Currency:       currency,
+		Category:       body.Category,
+		Attributes:     string(body.Attributes),
+		Condition:      body.Condition,
+		PickupLocation: body.PickupLocation,
+		Status:         string(models.ListingDraft),
+	}, user)
+	if errors.Is(err, errCategoryAttributesUnavailable) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template := templateFromListing(body.Name, listing)
+	if err := database.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"template": template,
+	})
+}
+
+// SaveListingAsTemplate saves one of the user's listings as a template, with its own
+// copies of the listing's images
+func SaveListingAsTemplate(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body SaveListingTemplateDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	template := templateFromListing(body.Name, listing)
+
+	imageURLs, err := services.CopyImages(c.Request.Context(), listing.ImageURLs, &user, "templates")
+	if err != nil {
+		log.Printf("warning: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy images"})
+		return
+	}
+	template.ImageURLs = imageURLs
+
+	if err := database.DB.Create(&template).Error; err != nil {
+		services.DeleteImages(c.Request.Context(), imageURLs)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"template": template,
+	})
+}
+
+// RenameListingTemplate changes a template's name
+func RenameListingTemplate(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body SaveListingTemplateDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+
+	templateID := c.Param("id")
+	var template models.ListingTemplate
+	if err := database.DB.First(&template, "id = ? AND user_id = ?", templateID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if err := database.DB.Model(&template).Update("name", strings.TrimSpace(body.Name)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"template": template,
+	})
+}
+
+// DeleteListingTemplate removes a template and its images, listings made from it keep theirs
+func DeleteListingTemplate(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	templateID := c.Param("id")
+	var template models.ListingTemplate
+	if err := database.DB.First(&template, "id = ? AND user_id = ?", templateID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&template).Error; err != nil {
+			return err
+		}
+		return services.QueueImageDeletes(tx, template.ImageURLs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		return
+	}
+
+	services.WakeStorageOutbox()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// CreateListingFromTemplate creates a listing from a template, checked against the
+// current rules since the category schema may have changed since it was saved
+func CreateListingFromTemplate(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body NewListingFromDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	templateID := c.Param("id")
+	var template models.ListingTemplate
+	if err := database.DB.First(&template, "id = ? AND user_id = ?", templateID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	listing, status, err := copyListingFrom(c.Request.Context(), listingInput{
+		Title:          template.Title,
+		Description:    template.Description,
+		Price:          template.Price,
+		Currency:       template.Currency,
+		Category:       template.Category,
+		Attributes:     attributesJSON(template.Attributes),
+		Condition:      conditionOrEmpty(template.Condition),
+		PickupLocation: template.PickupLocationID,
+		Status:         body.Status,
+	}, services.ListingImagesFromURLs(template.ImageURLs), user)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
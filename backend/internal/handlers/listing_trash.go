@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrashedListingResponse struct {
+	Listing         models.Listing `json:"listing"`
+	RestorableUntil time.Time      `json:"restorable_until"`
+}
+
+// softDeleteListing records who deleted the listing and moves it to the trash
+func softDeleteListing(listing *models.Listing, actor models.ListingActor) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(listing).Update("deleted_by", actor).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(listing).Error; err != nil {
+			return err
+		}
+
+		listing.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		return nil
+	})
+}
+
+// restoreListing takes a listing out of the trash
+func restoreListing(listing *models.Listing) error {
+	if err := database.DB.Unscoped().Model(listing).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error; err != nil {
+		return err
+	}
+
+	listing.DeletedAt = gorm.DeletedAt{}
+	listing.DeletedBy = nil
+	return nil
+}
+
+// GetListingTrash lists the listings the user deleted that can still be restored
+func GetListingTrash(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	retention := services.ListingTrashRetention()
+
+	var listings []models.Listing
+	if err := database.DB.Unscoped().
+		Where("user_id = ? AND deleted_by = ?", user.ID, models.ListingActorOwner).
+		Where("deleted_at > ? AND images_purged_at IS NULL", time.Now().Add(-retention)).
+		Order("deleted_at DESC").
+		Find(&listings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted listings"})
+		return
+	}
+
+	response := []TrashedListingResponse{}
+	for _, listing := range listings {
+		response = append(response, TrashedListingResponse{
+			Listing:         listing,
+			RestorableUntil: listing.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreListing brings back a listing the user deleted within the retention period
+func RestoreListing(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	// listings deleted by a moderator can only be restored by a moderator
+	listingID := c.Param("id")
+	var listing models.Listing
+	if err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_by = ?", models.ListingActorOwner).
+		First(&listing, "id = ? AND user_id = ?", listingID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted listing not found"})
+		return
+	}
+
+	if listing.ImagesPurgedAt != nil || listing.DeletedAt.Time.Before(time.Now().Add(-services.ListingTrashRetention())) {
+		c.JSON(http.StatusGone, gin.H{"error": "Listing can no longer be restored"})
+		return
+	}
+
+	if err := restoreListing(&listing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore listing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
+
+// AdminRestoreListing brings back any deleted listing whose images were not purged yet
+func AdminRestoreListing(c *gin.Context) {
+	listingID := c.Param("id")
+
+	var listing models.Listing
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&listing, "id = ?", listingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted listing not found"})
+		return
+	}
+
+	if listing.ImagesPurgedAt != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Listing images were already purged"})
+		return
+	}
+
+	if err := restoreListing(&listing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore listing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"listing": listing,
+	})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageParams are the query parameters shared by cursor-paginated endpoints
+type PageParams struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+	Sort   string `form:"sort"`
+}
+
+func (p PageParams) limit() int {
+	if p.Limit <= 0 {
+		return defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return p.Limit
+}
+
+type cursorKind int
+
+const (
+	cursorTime cursorKind = iota
+	cursorNumber
+)
+
+// sortSpec describes a stable keyset ordering: column first, then id as tie-breaker
+type sortSpec struct {
+	name     string
+	column   string
+	idColumn string
+	desc     bool
+	kind     cursorKind
+}
+
+const (
+	SortNewest         = "newest"
+	SortPriceAsc       = "price_asc"
+	SortPriceDesc      = "price_desc"
+	SortMostWishlisted = "most_wishlisted"
+)
+
+var listingSorts = map[string]sortSpec{
+	SortNewest:         {name: SortNewest, column: "listings.created_at", idColumn: "listings.id", desc: true, kind: cursorTime},
+	SortPriceAsc:       {name: SortPriceAsc, column: "listings.price_base", idColumn: "listings.id", desc: false, kind: cursorNumber},
+	SortPriceDesc:      {name: SortPriceDesc, column: "listings.price_base", idColumn: "listings.id", desc: true, kind: cursorNumber},
+	SortMostWishlisted: {name: SortMostWishlisted, column: "listings.wishlist_count", idColumn: "listings.id", desc: true, kind: cursorNumber},
+}
+
+// listingSort resolves the requested sort, defaulting to newest first
+func (p PageParams) listingSort() (sortSpec, error) {
+	if p.Sort == "" {
+		return listingSorts[SortNewest], nil
+	}
+
+	spec, ok := listingSorts[p.Sort]
+	if !ok {
+		return sortSpec{}, fmt.Errorf("invalid sort %q", p.Sort)
+	}
+	return spec, nil
+}
+
+// newestFirst is the ordering used by endpoints without sort options
+func newestFirst(table string) sortSpec {
+	return sortSpec{
+		name:     SortNewest,
+		column:   table + ".created_at",
+		idColumn: table + ".id",
+		desc:     true,
+		kind:     cursorTime,
+	}
+}
+
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+func encodeCursor(spec sortSpec, value any, id uint) string {
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(pageCursor{Sort: spec.name, Value: raw, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(spec sortSpec, encoded string) (any, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, errInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != spec.name {
+		return nil, 0, errInvalidCursor
+	}
+
+	switch spec.kind {
+	case cursorTime:
+		var t time.Time
+		if err := json.Unmarshal(cursor.Value, &t); err != nil {
+			return nil, 0, errInvalidCursor
+		}
+		return t, cursor.ID, nil
+	default:
+		var n float64
+		if err := json.Unmarshal(cursor.Value, &n); err != nil {
+			return nil, 0, errInvalidCursor
+		}
+		return n, cursor.ID, nil
+	}
+}
+
+// paginate orders the query by spec, continues after the cursor and fetches
+// one extra row so the caller can tell whether another page exists
+func paginate(query *gorm.DB, params PageParams, spec sortSpec) (*gorm.DB, error) {
+	direction, comparison := "ASC", ">"
+	if spec.desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if params.Cursor != "" {
+		value, id, err := decodeCursor(spec, params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s, %s) %s (?, ?)", spec.column, spec.idColumn, comparison),
+			value, id,
+		)
+	}
+
+	return query.
+		Order(fmt.Sprintf("%s %s, %s %s", spec.column, direction, spec.idColumn, direction)).
+		Limit(params.limit() + 1), nil
+}
+
+// trimPage drops the look-ahead row and returns the cursor for the next page, if any
+func trimPage[T any](rows []T, params PageParams, cursorOf func(T) string) ([]T, *string) {
+	limit := params.limit()
+	if len(rows) <= limit {
+		return rows, nil
+	}
+
+	rows = rows[:limit]
+	next := cursorOf(rows[limit-1])
+	return rows, &next
+}
+
+// listingCursor picks the value a listing is ordered by under spec
+func listingCursor(spec sortSpec) func(models.Listing) string {
+	return func(listing models.Listing) string {
+		switch spec.name {
+		case SortPriceAsc, SortPriceDesc:
+			return encodeCursor(spec, listing.PriceBase, listing.ID)
+		case SortMostWishlisted:
+			return encodeCursor(spec, listing.WishlistCount, listing.ID)
+		default:
+			return encodeCursor(spec, listing.CreatedAt, listing.ID)
+		}
+	}
+}
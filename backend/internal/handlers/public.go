@@ -1,42 +1,91 @@
 package handlers
 
 import (
+	"errors"
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
+	"gin-backend/internal/services"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ListingResponse struct {
-	Listing      models.Listing `json:"listing"`
-	IsInWishlist bool           `json:"is_in_wishlist"`
+	Listing        models.Listing        `json:"listing"`
+	IsInWishlist   bool                  `json:"is_in_wishlist"`
+	ConvertedPrice *ConvertedPrice       `json:"converted_price,omitempty"`
+	PriceHistory   []models.ListingPrice `json:"price_history,omitempty"`
+}
+
+type ListingsPageResponse struct {
+	Listings   []ListingResponse `json:"listings"`
+	NextCursor *string           `json:"next_cursor"`
 }
 
 func GetListings(c *gin.Context) {
-	// 1. Just find all listings
+	// 1. Read pagination and sort params
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec, err := page.listingSort()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := viewerCurrency(c, c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rates, err := services.LoadExchangeRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+
+	query, err := paginate(database.DB.Model(&models.Listing{}).Where("listings.status IN ?", models.VisibleListingStatuses), page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	// 2. Find one page of listings
 	var listings []models.Listing
-	if err := database.DB.Find(&listings).Error; err != nil {
+	// cards only show the cover, so that's the only image loaded
+	if err := query.Preload("PickupLocation").Preload("Images", "is_cover = ?", true).Find(&listings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
 		return
 	}
 
-	// 2. Check if user is authenticated
+	listings, nextCursor := trimPage(listings, page, listingCursor(spec))
+
+	// 3. Check if user is authenticated
 	userAny, exists := c.Get("user")
 	if !exists {
 		var response []ListingResponse
 		for _, listing := range listings {
 			response = append(response, ListingResponse{
-				Listing:      listing,
-				IsInWishlist: false,
+				Listing:        listing,
+				IsInWishlist:   false,
+				ConvertedPrice: convertListingPrice(rates, listing, currency),
 			})
 		}
-		c.JSON(http.StatusOK, response)
+		c.JSON(http.StatusOK, ListingsPageResponse{
+			Listings:   response,
+			NextCursor: nextCursor,
+		})
 		return
 	}
 
-	// 3. Get wishlisted listing IDs
+	// 4. Get wishlisted listing IDs
 	user := userAny.(models.User)
 	listingIDs := make([]uint, len(listings))
 	for i, listing := range listings {
@@ -54,16 +103,20 @@ func GetListings(c *gin.Context) {
 		wishlistMap[id] = true
 	}
 
-	// 4. Build response with wishlist status
+	// 5. Build response with wishlist status
 	var response []ListingResponse
 	for _, listing := range listings {
 		response = append(response, ListingResponse{
-			Listing:      listing,
-			IsInWishlist: wishlistMap[listing.ID],
+			Listing:        listing,
+			IsInWishlist:   wishlistMap[listing.ID],
+			ConvertedPrice: convertListingPrice(rates, listing, currency),
 		})
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, ListingsPageResponse{
+		Listings:   response,
+		NextCursor: nextCursor,
+	})
 }
 
 func GetListing(c *gin.Context) {
@@ -79,8 +132,11 @@ func GetListing(c *gin.Context) {
 		userID = user.ID
 	}
 
-	// query that preloads user
-	query := database.DB.Preload("User")
+	// query that preloads user, pickup location and images with their alt text
+	query := database.DB.Preload("User").Preload("PickupLocation").
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		})
 
 	// find listing in db with query that preloads user
 	if err := query.First(&listing, "id = ?", listingID).Error; err != nil {
@@ -88,8 +144,15 @@ func GetListing(c *gin.Context) {
 		return
 	}
 
+	// drafts, expired, archived and removed listings are only visible to their owner
+	isOwner := userExists && listing.UserID == userID
+	if !isOwner && !listing.Status.IsViewable() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
 	// if user is authenticated and owns listing -> load ai report
-	if userExists && listing.UserID == userID {
+	if isOwner {
 		database.DB.Preload("AIPriceReport").First(&listing, "id = ?", listingID)
 	}
 
@@ -103,9 +166,29 @@ func GetListing(c *gin.Context) {
 		isInWishlist = count > 0
 	}
 
+	// show the price in the viewer's currency too
+	var convertedPrice *ConvertedPrice
+	if currency, err := viewerCurrency(c, c.Query("currency")); err == nil {
+		if rates, err := services.LoadExchangeRates(); err == nil {
+			convertedPrice = convertListingPrice(rates, listing, currency)
+		}
+	}
+
+	// price changes for the chart
+	var priceHistory []models.ListingPrice
+	if err := database.DB.Where("listing_id = ?", listing.ID).Order("created_at, id").Find(&priceHistory).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price history"})
+		return
+	}
+
+	// count the view for the seller's analytics
+	trackListingEvent(c, listing, models.ListingEventView)
+
 	c.JSON(http.StatusOK, ListingResponse{
-		listing,
-		isInWishlist,
+		Listing:        listing,
+		IsInWishlist:   isInWishlist,
+		ConvertedPrice: convertedPrice,
+		PriceHistory:   priceHistory,
 	})
 }
 
@@ -132,7 +215,9 @@ func GetUserWithListing(c *gin.Context) {
 	var otherUser models.User
 
 	// get Dan and his listings from database
-	if err := database.DB.Preload("Listings").First(&otherUser, "id = ?", otherUserID).Error; err != nil {
+	if err := database.DB.Preload("Listings", "status IN ?", models.VisibleListingStatuses).
+		Preload("Listings.Images", "is_cover = ?", true).
+		First(&otherUser, "id = ?", otherUserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -185,17 +270,50 @@ func GetUserWithListing(c *gin.Context) {
 }
 
 type SearchParams struct {
-	Query    string `form:"query"`
-	Page     int    `form:"page" binding:"min=1"`
-	Category string `form:"category"`
-	// MinPrice float64 `form:"min_price"`
-	// MaxPrice float64 `form:"max_price"`
-	// Sort     string  `form:"sort"`
+	Query         string   `form:"query"`
+	Page          int      `form:"page" binding:"min=1"`
+	Limit         int      `form:"limit" binding:"omitempty,min=1,max=50"`
+	Category      string   `form:"category"`
+	MinPrice      *float64 `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice      *float64 `form:"max_price" binding:"omitempty,min=0"`
+	Currency      string   `form:"currency"`
+	Sort          string   `form:"sort"`
+	University    string   `form:"university"`
+	MinRating     *float64 `form:"min_rating" binding:"omitempty,min=0,max=5"`
+	HasImages     *bool    `form:"has_images"`
+	IncludeClosed bool     `form:"include_closed"`
+	CreatedWithin string   `form:"created_within"`
+	Lang          string   `form:"lang"`
+	Facets        bool     `form:"facets"`
+	Conditions    []string `form:"condition"`
+	// pickup filters, either one location or everything within radius_km of a point
+	PickupLocationID *uint    `form:"pickup_location_id"`
+	Lat              *float64 `form:"lat" binding:"omitempty,min=-90,max=90"`
+	Lng              *float64 `form:"lng" binding:"omitempty,min=-180,max=180"`
+	RadiusKm         *float64 `form:"radius_km" binding:"omitempty,gt=0,max=100"`
+	// Attributes holds attr.<key> filters, they are parsed separately since keys are
+	// dynamic and resolved against the category schema once per search
+	Attributes map[string]string `form:"-"`
+	// AttributeFilters are Attributes with their types, the ones the query uses
+	AttributeFilters []AttributeFilter `form:"-"`
+}
+
+type SearchHighlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type SearchResult struct {
+	ListingResponse
+	Rank      float64          `json:"rank,omitempty"`
+	Highlight *SearchHighlight `json:"highlight,omitempty"`
 }
 
 type SearchResponse struct {
-	Listings []ListingResponse `json:"listings"`
-	Total    int64             `json:"total"`
+	Listings   []SearchResult `json:"listings"`
+	Total      int64          `json:"total"`
+	DidYouMean string         `json:"did_you_mean,omitempty"`
+	Facets     *SearchFacets  `json:"facets,omitempty"`
 }
 
 func Search(c *gin.Context) {
@@ -206,85 +324,140 @@ func Search(c *gin.Context) {
 		return
 	}
 
-	// 2. Build base query
-	const limit int = 10
-	offset := (params.Page - 1) * limit
-
-	var listings []models.Listing
-	var total int64
-
-	query := database.DB.Model(&models.Listing{})
+	attributes, err := parseAttributeFilters(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	params.Attributes = attributes
 
-	if params.Query != "" {
-		searchPattern := "%" + params.Query + "%"
-		query = query.Where(
-			"title ILIKE ? OR description ILIKE ?",
-			searchPattern, searchPattern,
-		)
+	params.Currency, err = viewerCurrency(c, params.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if params.Category != "" {
-		query = query.Where("LOWER(category) = LOWER(?)", params.Category)
+	if err := params.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Get total count before applying limit/offset
-	if err := query.Count(&total).Error; err != nil {
+	params.AttributeFilters, err = resolveAttributeFilters(params.Category, params.Attributes)
+	if errors.Is(err, errCategoryAttributesUnavailable) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Retrieve the paginated resources from DB
-	if err := query.Limit(limit).Offset(offset).Find(&listings).Error; err != nil {
+	// 2. Build filtered query
+	limit := params.limit()
+	offset := (params.Page - 1) * limit
+
+	var listings []models.Listing
+	var total int64
+
+	query := applySearchFilters(database.DB.Model(&models.Listing{}), params)
+
+	// Get total count before applying limit/offset
+	if err := query.Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
 		return
 	}
 
-	// 3. Check if user is authenticated
-	userAny, exists := c.Get("user")
-	if !exists {
-		var response []ListingResponse
-		for _, listing := range listings {
-			response = append(response, ListingResponse{
-				Listing:      listing,
-				IsInWishlist: false,
-			})
+	// Count matches per filter value when asked
+	var facets *SearchFacets
+	if params.Facets {
+		var err error
+		facets, err = loadSearchFacets(params)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+			return
 		}
+	}
+
+	// Offer a spelling correction when a query finds nothing
+	if total == 0 && strings.TrimSpace(params.Query) != "" {
 		c.JSON(http.StatusOK, SearchResponse{
-			Listings: response,
-			Total:    total,
+			Listings:   []SearchResult{},
+			Total:      0,
+			DidYouMean: didYouMean(params.Query),
+			Facets:     facets,
 		})
 		return
 	}
 
-	// 4. Get wishlisted listing IDs
-	user := userAny.(models.User)
+	// Retrieve the paginated resources from DB
+	if err := query.Preload("PickupLocation").Preload("Images", "is_cover = ?", true).Order(searchOrder(params)).Limit(limit).Offset(offset).Find(&listings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
 	listingIDs := make([]uint, len(listings))
 	for i, listing := range listings {
 		listingIDs[i] = listing.ID
 	}
 
-	// Single query to get all wishlisted IDs
-	var wishlistedIDs []uint
-	database.DB.Model(&models.WishlistListing{}).
-		Where("user_id = ? AND listing_id IN ?", user.ID, listingIDs).
-		Pluck("listing_id", &wishlistedIDs)
+	// 3. Rank and highlight the matches on this page
+	var highlights map[uint]searchHighlightRow
+	if strings.TrimSpace(params.Query) != "" && len(listingIDs) > 0 {
+		var err error
+		highlights, err = loadSearchHighlights(params, listingIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+			return
+		}
+	}
 
+	// 4. Get wishlisted listing IDs if user is authenticated
 	wishlistMap := make(map[uint]bool)
-	for _, id := range wishlistedIDs {
-		wishlistMap[id] = true
+	if userAny, exists := c.Get("user"); exists {
+		user := userAny.(models.User)
+
+		// Single query to get all wishlisted IDs
+		var wishlistedIDs []uint
+		database.DB.Model(&models.WishlistListing{}).
+			Where("user_id = ? AND listing_id IN ?", user.ID, listingIDs).
+			Pluck("listing_id", &wishlistedIDs)
+
+		for _, id := range wishlistedIDs {
+			wishlistMap[id] = true
+		}
 	}
 
-	// 5. Build response with wishlist status
-	var response []ListingResponse
+	rates, err := services.LoadExchangeRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	// 5. Build response with wishlist status, converted prices and highlights
+	var response []SearchResult
 	for _, listing := range listings {
-		response = append(response, ListingResponse{
-			Listing:      listing,
-			IsInWishlist: wishlistMap[listing.ID],
-		})
+		result := SearchResult{
+			ListingResponse: ListingResponse{
+				Listing:        listing,
+				IsInWishlist:   wishlistMap[listing.ID],
+				ConvertedPrice: convertListingPrice(rates, listing, params.Currency),
+			},
+		}
+
+		if row, ok := highlights[listing.ID]; ok {
+			result.Rank = row.Rank
+			result.Highlight = &SearchHighlight{
+				Title:       renderHighlight(row.Title),
+				Description: renderHighlight(row.Description),
+			}
+		}
+
+		response = append(response, result)
 	}
 
 	c.JSON(http.StatusOK, SearchResponse{
 		Listings: response,
 		Total:    total,
+		Facets:   facets,
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"gin-backend/internal/models"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -147,20 +148,40 @@ func GetUserRatings(c *gin.Context) {
 		return
 	}
 
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec := newestFirst("ratings")
+	query, err := paginate(database.DB.Where("user_id = ?", userID), page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
 	var ratings []models.Rating
-	if err := database.DB.Where("user_id = ?", userID).
+	if err := query.
 		Preload("Rater").
-		Preload("Listing").
-		Order("created_at DESC").
+		Preload("Listing", func(db *gorm.DB) *gorm.DB {
+			// ratings keep showing the listing they were given for after it is deleted
+			return db.Unscoped()
+		}).
 		Find(&ratings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	ratings, nextCursor := trimPage(ratings, page, func(rating models.Rating) string {
+		return encodeCursor(spec, rating.CreatedAt, rating.ID)
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"ratings":        ratings,
 		"average_rating": user.AverageRating,
 		"rating_count":   user.RatingCount,
+		"next_cursor":    nextCursor,
 	})
 }
 
@@ -324,6 +345,71 @@ func DeleteRating(c *gin.Context) {
 	})
 }
 
+type ratingExportRow struct {
+	ID           uint
+	CreatedAt    time.Time
+	SellerID     uint
+	SellerName   string
+	ListingID    *uint
+	ListingTitle *string
+	Rating       int
+	Comment      string
+}
+
+// ratingExportColumns are the columns ExportMyRatingsGiven can write
+var ratingExportColumns = []exportColumn[ratingExportRow]{
+	{"id", func(r ratingExportRow) any { return r.ID }},
+	{"created_at", func(r ratingExportRow) any { return r.CreatedAt }},
+	{"seller_id", func(r ratingExportRow) any { return r.SellerID }},
+	{"seller", func(r ratingExportRow) any { return r.SellerName }},
+	{"listing_id", func(r ratingExportRow) any { return r.ListingID }},
+	{"listing_title", func(r ratingExportRow) any { return r.ListingTitle }},
+	{"rating", func(r ratingExportRow) any { return r.Rating }},
+	{"comment", func(r ratingExportRow) any { return r.Comment }},
+}
+
+// ExportMyRatingsGiven streams the ratings the user gave as CSV or NDJSON
+func ExportMyRatingsGiven(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var params ExportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns, err := selectExportColumns(ratingExportColumns, params.Columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ratings keep their listing's title after the listing is deleted
+	query := database.DB.Table("ratings").
+		Select(`ratings.id, ratings.created_at, ratings.user_id AS seller_id, users.name AS seller_name,
+			ratings.listing_id, listings.title AS listing_title, ratings.rating, ratings.comment`).
+		Joins("JOIN users ON users.id = ratings.user_id").
+		Joins("LEFT JOIN listings ON listings.id = ratings.listing_id").
+		Where("ratings.rater_id = ?", user.ID).
+		Order("ratings.created_at DESC, ratings.id DESC")
+
+	streamExport(c, query, "ratings-given", params.Format, columns)
+}
+
 // GetMyRatingsGiven gets all ratings given by the current user
 func GetMyRatingsGiven(c *gin.Context) {
 	userAny, exists := c.Get("user")
@@ -342,17 +428,39 @@ func GetMyRatingsGiven(c *gin.Context) {
 		return
 	}
 
+	var page PageParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	spec := newestFirst("ratings")
+	query, err := paginate(database.DB.Where("rater_id = ?", user.ID), page, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
 	var ratings []models.Rating
-	if err := database.DB.Where("rater_id = ?", user.ID).
+	if err := query.
 		Preload("User").
-		Preload("Listing").
-		Order("created_at DESC").
+		Preload("Listing", func(db *gorm.DB) *gorm.DB {
+			// ratings keep showing the listing they were given for after it is deleted
+			return db.Unscoped()
+		}).
 		Find(&ratings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, ratings)
+	ratings, nextCursor := trimPage(ratings, page, func(rating models.Rating) string {
+		return encodeCursor(spec, rating.CreatedAt, rating.ID)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"ratings":     ratings,
+		"next_cursor": nextCursor,
+	})
 }
 
 // CheckUserRating checks if current user has rated a specific seller for a listing
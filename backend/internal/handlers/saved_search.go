@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const maxSavedSearchesPerUser = 20
+
+// saved searches evaluated against a new listing in one query
+const savedSearchMatchBatch = 100
+
+type CreateSavedSearchDTO struct {
+	Name      string                   `json:"name" binding:"required"`
+	Params    models.SavedSearchParams `json:"params"`
+	Frequency string                   `json:"frequency"`
+}
+
+type UpdateSavedSearchDTO struct {
+	Name      *string                   `json:"name"`
+	Params    *models.SavedSearchParams `json:"params"`
+	Frequency *string                   `json:"frequency"`
+}
+
+// searchParamsFromSaved turns stored filters into search params, only visible listings match
+func searchParamsFromSaved(saved models.SavedSearchParams) SearchParams {
+	return SearchParams{
+		Query:      saved.Query,
+		Category:   saved.Category,
+		MinPrice:   saved.MinPrice,
+		MaxPrice:   saved.MaxPrice,
+		Currency:   strings.ToUpper(saved.Currency),
+		University: saved.University,
+		MinRating:  saved.MinRating,
+		HasImages:  saved.HasImages,
+		Lang:       saved.Lang,
+	}
+}
+
+func validateSavedSearchParams(saved models.SavedSearchParams) error {
+	if saved == (models.SavedSearchParams{Lang: saved.Lang, Currency: saved.Currency}) {
+		return errors.New("Saved search needs at least one filter")
+	}
+
+	if saved.MinPrice != nil && *saved.MinPrice < 0 || saved.MaxPrice != nil && *saved.MaxPrice < 0 {
+		return errors.New("Price cannot be negative")
+	}
+
+	if saved.Currency != "" {
+		if _, err := normalizeCurrency(saved.Currency); err != nil {
+			return err
+		}
+	}
+
+	if saved.MinRating != nil && (*saved.MinRating < 0 || *saved.MinRating > 5) {
+		return errors.New("min_rating must be between 0 and 5")
+	}
+
+	return searchParamsFromSaved(saved).validate()
+}
+
+func validateSavedSearchFrequency(frequency string) error {
+	switch models.SavedSearchFrequency(frequency) {
+	case models.SavedSearchInstant, models.SavedSearchDaily:
+		return nil
+	default:
+		return errors.New("frequency must be instant or daily")
+	}
+}
+
+// GetSavedSearches lists the user's saved searches
+func GetSavedSearches(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var searches []models.SavedSearch
+	if err := database.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// CreateSavedSearch saves a filter combination to be alerted about
+func CreateSavedSearch(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body CreateSavedSearchDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	if strings.TrimSpace(body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+
+	if body.Frequency == "" {
+		body.Frequency = string(models.SavedSearchInstant)
+	}
+
+	if err := validateSavedSearchFrequency(body.Frequency); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateSavedSearchParams(body.Params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.SavedSearch{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxSavedSearchesPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("You can save at most %d searches", maxSavedSearchesPerUser),
+		})
+		return
+	}
+
+	search := models.SavedSearch{
+		UserID:    user.ID,
+		Name:      body.Name,
+		Params:    body.Params,
+		Frequency: models.SavedSearchFrequency(body.Frequency),
+	}
+	if err := database.DB.Create(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"saved_search": search,
+	})
+}
+
+// UpdateSavedSearch renames a saved search or changes its filters or frequency
+func UpdateSavedSearch(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	searchID := c.Param("id")
+	var search models.SavedSearch
+	if err := database.DB.First(&search, "id = ? AND user_id = ?", searchID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return
+	}
+
+	var body UpdateSavedSearchDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// validate input data
+	if body.Name != nil && strings.TrimSpace(*body.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+
+	if body.Frequency != nil {
+		if err := validateSavedSearchFrequency(*body.Frequency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if body.Params != nil {
+		if err := validateSavedSearchParams(*body.Params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if body.Name != nil {
+		search.Name = *body.Name
+	}
+
+	if body.Params != nil {
+		search.Params = *body.Params
+	}
+
+	if body.Frequency != nil {
+		search.Frequency = models.SavedSearchFrequency(*body.Frequency)
+	}
+
+	if err := database.DB.Save(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"saved_search": search,
+	})
+}
+
+// DeleteSavedSearch removes a saved search and its matches
+func DeleteSavedSearch(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	searchID := c.Param("id")
+	var search models.SavedSearch
+	if err := database.DB.First(&search, "id = ? AND user_id = ?", searchID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// notifySavedSearchMatches records the listing against every saved search it
+// satisfies and alerts instant subscribers. Daily subscribers get the match in
+// their next digest. Meant to run in the background after a listing is published.
+func notifySavedSearchMatches(listing models.Listing) {
+	if listing.Status != models.ListingActive {
+		return
+	}
+
+	// only searches without a category or with the listing's category or one of its parents can match
+	var searches []models.SavedSearch
+	if err := database.DB.
+		Where("user_id <> ?", listing.UserID).
+		Where(`(COALESCE(params->>'category', '') = '' OR LOWER(params->>'category') IN (
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id, slug FROM categories WHERE slug = ?
+				UNION ALL
+				SELECT c.id, c.parent_id, c.slug FROM categories c JOIN ancestors a ON c.id = a.parent_id
+			)
+			SELECT slug FROM ancestors
+		))`, listing.Category).
+		Find(&searches).Error; err != nil {
+		log.Printf("warning: failed to fetch saved searches: %v", err)
+		return
+	}
+
+	matched, err := matchSavedSearches(listing, searches)
+	if err != nil {
+		log.Printf("warning: failed to evaluate saved searches: %v", err)
+		return
+	}
+
+	for _, search := range searches {
+		if !matched[search.ID] {
+			continue
+		}
+
+		// a listing matches a search once, later edits don't alert again
+		match := models.SavedSearchMatch{
+			SavedSearchID: search.ID,
+			ListingID:     listing.ID,
+		}
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&match)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		if search.Frequency != models.SavedSearchInstant {
+			continue
+		}
+
+		listingID := listing.ID
+		notification := models.Notification{
+			UserID:    search.UserID,
+			Type:      models.NotificationSavedSearchMatch,
+			Title:     fmt.Sprintf("New listing for \"%s\"", search.Name),
+			Body:      fmt.Sprintf("%s (%.2f %s)", listing.Title, listing.Price, listing.Currency),
+			ListingID: &listingID,
+		}
+		if err := services.Notify(notification, true); err != nil {
+			log.Printf("warning: %v", err)
+			continue
+		}
+
+		database.DB.Model(&match).Update("notified_at", time.Now())
+	}
+}
+
+// matchSavedSearches returns the IDs of the searches the listing satisfies. Each
+// search selects its ID when the listing passes its filters, and a batch of them
+// runs as a single UNION ALL query.
+func matchSavedSearches(listing models.Listing, searches []models.SavedSearch) (map[uint]bool, error) {
+	matched := make(map[uint]bool)
+
+	for start := 0; start < len(searches); start += savedSearchMatchBatch {
+		batch := searches[start:min(start+savedSearchMatchBatch, len(searches))]
+
+		parts := make([]string, len(batch))
+		queries := make([]interface{}, len(batch))
+		for i, search := range batch {
+			query := database.DB.Model(&models.Listing{}).
+				Select("CAST(? AS bigint) AS id", search.ID).
+				Where("listings.id = ?", listing.ID)
+			parts[i] = "(?)"
+			queries[i] = applySearchFilters(query, searchParamsFromSaved(search.Params))
+		}
+
+		var ids []uint
+		if err := database.DB.Raw(strings.Join(parts, " UNION ALL "), queries...).Scan(&ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			matched[id] = true
+		}
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"html"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultSearchLimit = 10
+
+// SortRelevance orders full-text matches by rank, it is the default when a query is given
+const SortRelevance = "relevance"
+
+// textSearchConfigs maps the UI language to Postgres text search configurations.
+// Kazakh has no stemmer in Postgres, so it relies on the unstemmed simple config.
+var textSearchConfigs = map[string][]string{
+	"en": {"english", "simple"},
+	"ru": {"russian", "simple"},
+	"kk": {"simple"},
+}
+
+var defaultTextSearchConfigs = []string{"english", "russian", "simple"}
+
+// ts_headline wraps matches in control characters so the text can be
+// HTML-escaped before the markers are turned into <mark> tags. They're stripped
+// from the text first, so only ts_headline's own markers become tags.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+
+	highlightMarkers = highlightStart + highlightStop
+)
+
+var (
+	titleHeadlineOptions       = fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true", highlightStart, highlightStop)
+	descriptionHeadlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \"", highlightStart, highlightStop)
+)
+
+var createdWithinPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// exchangeRateSQL selects how many units of a currency one base currency buys
+const exchangeRateSQL = "(SELECT rate FROM exchange_rates WHERE currency = ?)"
+
+// currency is the currency price filters and facets are in, the base one unless given
+func (p SearchParams) currency() string {
+	if p.Currency == "" {
+		return models.BaseCurrency
+	}
+	return p.Currency
+}
+
+func (p SearchParams) limit() int {
+	if p.Limit <= 0 {
+		return defaultSearchLimit
+	}
+	return p.Limit
+}
+
+// validate checks the filter combinations that binding tags cannot express
+func (p SearchParams) validate() error {
+	if p.MinPrice != nil && p.MaxPrice != nil && *p.MinPrice > *p.MaxPrice {
+		return errors.New("min_price cannot be greater than max_price")
+	}
+
+	if p.Sort == SortRelevance {
+		if strings.TrimSpace(p.Query) == "" {
+			return errors.New("relevance sort requires a query")
+		}
+	} else if p.Sort != "" {
+		if _, ok := listingSorts[p.Sort]; !ok {
+			return fmt.Errorf("invalid sort %q", p.Sort)
+		}
+	}
+
+	if p.Lang != "" {
+		if _, ok := textSearchConfigs[p.Lang]; !ok {
+			return errors.New("lang must be one of en, ru, kk")
+		}
+	}
+
+	for _, condition := range p.Conditions {
+		if !models.ListingCondition(condition).Valid() {
+			return fmt.Errorf("invalid condition %q", condition)
+		}
+	}
+
+	if (p.Lat != nil || p.Lng != nil || p.RadiusKm != nil) && (p.Lat == nil || p.Lng == nil || p.RadiusKm == nil) {
+		return errors.New("lat, lng and radius_km must be given together")
+	}
+
+	if p.CreatedWithin != "" {
+		if _, ok := createdWithinPeriods[p.CreatedWithin]; !ok {
+			return errors.New("created_within must be one of 24h, 7d, 30d, 90d")
+		}
+	}
+
+	return nil
+}
+
+// applySearchFilters narrows the query to listings matching every filter in params
+func applySearchFilters(query *gorm.DB, params SearchParams) *gorm.DB {
+	if strings.TrimSpace(params.Query) != "" {
+		query = query.Where("listings.search_vector @@ ?", textSearchQuery(params))
+	}
+
+	// a category matches its subcategories too
+	if params.Category != "" {
+		query = query.Where("listings.category IN ("+categorySubtreeSQL+")", params.Category)
+	}
+
+	for _, filter := range params.AttributeFilters {
+		condition, args := attributeFilterCondition(filter)
+		query = query.Where(condition, args...)
+	}
+
+	// price bounds are in params.Currency, listings are compared by their base currency price
+	if params.MinPrice != nil {
+		query = query.Where("listings.price_base >= ? / "+exchangeRateSQL, *params.MinPrice, params.currency())
+	}
+
+	if params.MaxPrice != nil {
+		query = query.Where("listings.price_base <= ? / "+exchangeRateSQL, *params.MaxPrice, params.currency())
+	}
+
+	if len(params.Conditions) > 0 {
+		query = query.Where("listings.condition IN ?", params.Conditions)
+	}
+
+	if params.PickupLocationID != nil {
+		query = query.Where("listings.pickup_location_id = ?", *params.PickupLocationID)
+	}
+
+	if params.RadiusKm != nil && params.Lat != nil && params.Lng != nil {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM campus_locations WHERE campus_locations.id = listings.pickup_location_id AND "+pickupDistanceSQL+" <= ?)",
+			*params.Lat, *params.Lng, *params.Lat, *params.RadiusKm,
+		)
+	}
+
+	if params.University != "" {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM users WHERE users.id = listings.user_id AND LOWER(users.university) = LOWER(?))",
+			params.University,
+		)
+	}
+
+	if params.MinRating != nil {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM users WHERE users.id = listings.user_id AND users.average_rating >= ?)",
+			*params.MinRating,
+		)
+	}
+
+	if params.HasImages != nil {
+		if *params.HasImages {
+			query = query.Where("COALESCE(cardinality(listings.image_urls), 0) > 0")
+		} else {
+			query = query.Where("COALESCE(cardinality(listings.image_urls), 0) = 0")
+		}
+	}
+
+	// sold listings are shown on request, other states never reach search
+	if params.IncludeClosed {
+		query = query.Where("listings.status IN ?", models.ViewableListingStatuses)
+	} else {
+		query = query.Where("listings.status IN ?", models.VisibleListingStatuses)
+	}
+
+	if period, ok := createdWithinPeriods[params.CreatedWithin]; ok {
+		query = query.Where("listings.created_at >= ?", time.Now().Add(-period))
+	}
+
+	return query
+}
+
+// searchOrder returns the ORDER BY clause for the requested sort. Queries default
+// to relevance, browsing without a query defaults to newest first.
+func searchOrder(params SearchParams) clause.OrderBy {
+	hasQuery := strings.TrimSpace(params.Query) != ""
+	if params.Sort == SortRelevance || (params.Sort == "" && hasQuery) {
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(listings.search_vector, ?, 32) DESC, listings.id DESC",
+			Vars:               []interface{}{textSearchQuery(params)},
+			WithoutParentheses: true,
+		}}
+	}
+
+	spec, ok := listingSorts[params.Sort]
+	if !ok {
+		spec = listingSorts[SortNewest]
+	}
+
+	direction := "ASC"
+	if spec.desc {
+		direction = "DESC"
+	}
+
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL: fmt.Sprintf("%s %s, %s %s", spec.column, direction, spec.idColumn, direction),
+	}}
+}
+
+func searchConfigs(lang string) []string {
+	if configs, ok := textSearchConfigs[lang]; ok {
+		return configs
+	}
+	return defaultTextSearchConfigs
+}
+
+// textSearchQuery ORs the query as parsed by every configuration for the language,
+// so a word matches whether it is stemmed as English, Russian or left as typed
+func textSearchQuery(params SearchParams) clause.Expr {
+	configs := searchConfigs(params.Lang)
+
+	parts := make([]string, len(configs))
+	vars := make([]interface{}, len(configs))
+	for i, config := range configs {
+		parts[i] = fmt.Sprintf("websearch_to_tsquery('%s', ?)", config)
+		vars[i] = params.Query
+	}
+
+	return gorm.Expr("("+strings.Join(parts, " || ")+")", vars...)
+}
+
+type searchHighlightRow struct {
+	ID          uint
+	Rank        float64
+	Title       string
+	Description string
+}
+
+// loadSearchHighlights ranks and highlights only the listings on the current page,
+// ts_headline is too expensive to run over every match
+func loadSearchHighlights(params SearchParams, listingIDs []uint) (map[uint]searchHighlightRow, error) {
+	tsQuery := textSearchQuery(params)
+	config := searchConfigs(params.Lang)[0]
+
+	var rows []searchHighlightRow
+	err := database.DB.Model(&models.Listing{}).
+		Select(
+			"listings.id, "+
+				"ts_rank(listings.search_vector, ?, 32) AS rank, "+
+				"ts_headline(?::regconfig, translate(listings.title, ?, ''), ?, ?) AS title, "+
+				"ts_headline(?::regconfig, translate(COALESCE(listings.description, ''), ?, ''), ?, ?) AS description",
+			tsQuery,
+			config, highlightMarkers, tsQuery, titleHeadlineOptions,
+			config, highlightMarkers, tsQuery, descriptionHeadlineOptions,
+		).
+		Where("listings.id IN ?", listingIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	highlights := make(map[uint]searchHighlightRow, len(rows))
+	for _, row := range rows {
+		highlights[row.ID] = row
+	}
+	return highlights, nil
+}
+
+// renderHighlight escapes user text and turns the headline markers into <mark> tags
+func renderHighlight(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storage cleanup runs listed at once, newest first
+const storageGCRunsLimit = 50
+
+type StartStorageGCDTO struct {
+	DryRun *bool `json:"dry_run"`
+}
+
+// AdminGetStorageGCRuns lists recent runs of the orphaned image collector, the
+// orphan keys are only in a single run's report
+func AdminGetStorageGCRuns(c *gin.Context) {
+	var runs []models.StorageGCRun
+	if err := database.DB.Omit("orphan_keys").
+		Order("created_at DESC").
+		Limit(storageGCRunsLimit).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage cleanup runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// AdminGetStorageGCRun returns the full report of one run
+func AdminGetStorageGCRun(c *gin.Context) {
+	var run models.StorageGCRun
+	if err := database.DB.First(&run, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storage cleanup run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// AdminStartStorageGC starts a run right away. It's a dry run unless dry_run is
+// explicitly false.
+func AdminStartStorageGC(c *gin.Context) {
+	var body StartStorageGCDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	dryRun := body.DryRun == nil || *body.DryRun
+
+	run, err := services.StartStorageGC(dryRun)
+	if errors.Is(err, services.ErrStorageGCRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Storage cleanup is already running"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start storage cleanup"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// outbox entries listed at once, oldest first
+const storageOutboxListLimit = 100
+
+// AdminGetStorageOutbox lists storage operations still waiting to run, the dead
+// ones by default. Counts cover the whole outbox.
+func AdminGetStorageOutbox(c *gin.Context) {
+	status := models.StorageOutboxStatus(c.DefaultQuery("status", string(models.StorageOutboxDead)))
+	if status != models.StorageOutboxPending && status != models.StorageOutboxDead {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be pending or dead"})
+		return
+	}
+
+	var entries []models.StorageOutboxEntry
+	if err := database.DB.Where("status = ?", status).
+		Order("id").
+		Limit(storageOutboxListLimit).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage outbox"})
+		return
+	}
+
+	type statusCount struct {
+		Status models.StorageOutboxStatus
+		Count  int64
+	}
+	var counts []statusCount
+	if err := database.DB.Model(&models.StorageOutboxEntry{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage outbox"})
+		return
+	}
+
+	totals := gin.H{
+		string(models.StorageOutboxPending): int64(0),
+		string(models.StorageOutboxDead):    int64(0),
+	}
+	for _, count := range counts {
+		totals[string(count.Status)] = count.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"counts":  totals,
+	})
+}
+
+// AdminRetryStorageOutboxEntry gives a dead entry a fresh set of attempts
+func AdminRetryStorageOutboxEntry(c *gin.Context) {
+	result := database.DB.Model(&models.StorageOutboxEntry{}).
+		Where("id = ? AND status = ?", c.Param("id"), models.StorageOutboxDead).
+		Updates(map[string]interface{}{
+			"status":          models.StorageOutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"dead_at":         nil,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry storage operation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead storage operation not found"})
+		return
+	}
+
+	services.WakeStorageOutbox()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// AdminDeleteStorageOutboxEntry discards a dead entry, for objects that were
+// dealt with by hand. The orphaned image collector catches any left behind.
+func AdminDeleteStorageOutboxEntry(c *gin.Context) {
+	result := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.StorageOutboxDead).
+		Delete(&models.StorageOutboxEntry{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard storage operation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead storage operation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSuggestLimit = 8
+	// minimum pg_trgm similarity for a word to count as a likely typo
+	correctionThreshold = 0.3
+)
+
+type SuggestParams struct {
+	Query string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
+}
+
+type CategorySuggestion struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+type SuggestResponse struct {
+	Titles     []string             `json:"titles"`
+	Categories []CategorySuggestion `json:"categories"`
+}
+
+// Suggest returns typo-tolerant completions for the search box
+func Suggest(c *gin.Context) {
+	var params SuggestParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := strings.ToLower(strings.TrimSpace(params.Query))
+	if query == "" {
+		c.JSON(http.StatusOK, SuggestResponse{Titles: []string{}, Categories: []CategorySuggestion{}})
+		return
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultSuggestLimit
+	}
+
+	prefix := escapeLike(query) + "%"
+
+	// prefix matches first, then titles containing a word close to what was typed
+	titles := []string{}
+	if err := database.DB.Raw(`
+		SELECT MIN(title) AS title
+		FROM listings
+		WHERE status IN ? AND deleted_at IS NULL
+			AND (LOWER(title) LIKE ? OR ? <% LOWER(title))
+		GROUP BY LOWER(title)
+		ORDER BY BOOL_OR(LOWER(title) LIKE ?) DESC,
+			MAX(word_similarity(?, LOWER(title))) DESC,
+			MAX(wishlist_count) DESC
+		LIMIT ?`,
+		models.VisibleListingStatuses, prefix, query, prefix, query, limit,
+	).Scan(&titles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	// categories match by slug or by their name in any language
+	categories := []CategorySuggestion{}
+	if err := database.DB.Raw(`
+		SELECT categories.slug AS category, COUNT(*) AS count
+		FROM categories
+		JOIN listings ON listings.category = categories.slug
+		WHERE listings.status IN ? AND listings.deleted_at IS NULL
+			AND (
+				categories.slug LIKE ? OR ? <% categories.slug
+				OR EXISTS (
+					SELECT 1 FROM jsonb_each_text(categories.names) AS names
+					WHERE LOWER(names.value) LIKE ? OR ? <% LOWER(names.value)
+				)
+			)
+		GROUP BY categories.slug
+		ORDER BY count DESC
+		LIMIT 3`,
+		models.VisibleListingStatuses, prefix, query, prefix, query,
+	).Scan(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuggestResponse{
+		Titles:     titles,
+		Categories: categories,
+	})
+}
+
+// didYouMean replaces every query word missing from listing titles with the
+// closest known word. Returns an empty string when nothing could be corrected.
+func didYouMean(query string) string {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return ""
+	}
+
+	corrected := make([]string, len(words))
+	changed := false
+
+	for i, word := range words {
+		corrected[i] = word
+
+		var known int64
+		database.DB.Table("listing_title_words").Where("word = ?", word).Count(&known)
+		if known > 0 {
+			continue
+		}
+
+		var candidates []string
+		database.DB.Raw(`
+			SELECT word
+			FROM listing_title_words
+			WHERE word % ? AND similarity(word, ?) >= ?
+			ORDER BY similarity(word, ?) DESC, ndoc DESC
+			LIMIT 1`,
+			word, word, correctionThreshold, word,
+		).Scan(&candidates)
+
+		if len(candidates) > 0 {
+			corrected[i] = candidates[0]
+			changed = true
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return strings.Join(corrected, " ")
+}
+
+// escapeLike makes user input safe to embed in a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
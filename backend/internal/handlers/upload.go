@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"gin-backend/internal/services"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploads a user can have waiting for confirmation at once
+const maxPendingUploads = 20
+
+type CreateUploadDTO struct {
+	Purpose     string `json:"purpose" binding:"required,oneof=listing avatar"`
+	ContentType string `json:"content_type" binding:"required"`
+	ByteSize    int64  `json:"byte_size" binding:"required,min=1"`
+	SHA256      string `json:"sha256" binding:"required,len=64,hexadecimal"`
+}
+
+// CreateUpload hands out a presigned URL the client PUTs an image to directly,
+// so the file never passes through the API. The image can be used once the
+// upload is confirmed.
+func CreateUpload(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	var body CreateUploadDTO
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !services.UploadContentTypes[body.ContentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, WebP and GIF images can be uploaded"})
+		return
+	}
+
+	if body.ByteSize > services.MaxUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large"})
+		return
+	}
+
+	var pending int64
+	if err := database.DB.Model(&models.Upload{}).
+		Where("user_id = ? AND status = ?", user.ID, models.UploadPending).
+		Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+	if pending >= maxPendingUploads {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many uploads waiting for confirmation"})
+		return
+	}
+
+	upload, uploadURL, err := services.NewUpload(c.Request.Context(), user.ID, models.UploadPurpose(body.Purpose),
+		body.ContentType, body.ByteSize, strings.ToLower(body.SHA256))
+	if err != nil {
+		log.Printf("warning: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	if err := database.DB.Create(&upload).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"upload":     upload,
+		"upload_url": uploadURL,
+		"method":     http.MethodPut,
+		"headers":    gin.H{"Content-Type": upload.ContentType},
+	})
+}
+
+// ConfirmUpload verifies an uploaded file and turns it into an image that can be
+// attached to a listing or set as the avatar by its upload ID
+func ConfirmUpload(c *gin.Context) {
+	userAny, exists := c.Get("user")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	user, ok := userAny.(models.User)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Invalid user type",
+		})
+		return
+	}
+
+	uploadID := c.Param("id")
+	var upload models.Upload
+	if err := database.DB.First(&upload, "id = ? AND user_id = ?", uploadID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+
+	// confirming twice is harmless
+	if upload.Status == models.UploadConfirmed {
+		c.JSON(http.StatusOK, gin.H{"success": true, "upload": upload})
+		return
+	}
+
+	err := services.ConfirmUpload(c.Request.Context(), &upload)
+	if errors.Is(err, services.ErrUploadRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("warning: failed to confirm upload %d: %v", upload.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm upload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "upload": upload})
+}
@@ -1,23 +1,27 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
 	"gin-backend/internal/services"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// UpdateUserDTO changes only the fields that are set, an empty preferred_currency
+// goes back to the base currency
 type UpdateUserDTO struct {
-	Name         *string `json:"name"`
-	University   *string `json:"university"`
-	Phone        *string `json:"phone"`
-	TelegramLink *string `json:"telegram_link"`
-	Bio          *string `json:"bio"`
+	Name              *string `json:"name"`
+	University        *string `json:"university"`
+	Phone             *string `json:"phone"`
+	TelegramLink      *string `json:"telegram_link"`
+	Bio               *string `json:"bio"`
+	PreferredCurrency *string `json:"preferred_currency"`
 }
 
 func UpdateUser(c *gin.Context) {
@@ -49,6 +53,16 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	var preferredCurrency *string
+	if body.PreferredCurrency != nil && strings.TrimSpace(*body.PreferredCurrency) != "" {
+		currency, err := normalizeCurrency(*body.PreferredCurrency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		preferredCurrency = &currency
+	}
+
 	if body.Name != nil {
 		user.Name = *body.Name
 	}
@@ -69,6 +83,10 @@ func UpdateUser(c *gin.Context) {
 		user.Bio = *body.Bio
 	}
 
+	if body.PreferredCurrency != nil {
+		user.PreferredCurrency = preferredCurrency
+	}
+
 	// save user to db
 	if err := database.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -99,39 +117,7 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// find user listings
-		var listings []models.Listing
-		if err := tx.Where("user_id = ?", user.ID).Find(&listings).Error; err != nil {
-			return fmt.Errorf("failed to fetch user listings: %w", err)
-		}
-
-		// delete listing images
-		for _, listing := range listings {
-			for _, imgURL := range listing.ImageURLs {
-				if err := services.DeleteImageByURL(c.Request.Context(), imgURL); err != nil {
-					fmt.Printf("warning: failed to delete listing image %s: %v\n", imgURL, err)
-				}
-			}
-		}
-
-		// delete listings
-		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Listing{}).Error; err != nil {
-			return fmt.Errorf("failed to delete user listings: %w", err)
-		}
-
-		// delete user's avatar
-		if user.AvatarURL != "" {
-			if err := services.DeleteImageByURL(c.Request.Context(), user.AvatarURL); err != nil {
-				fmt.Printf("warning: failed to delete user avatar %s: %v\n", user.AvatarURL, err)
-			}
-		}
-
-		// delete user
-		if err := tx.Delete(&user).Error; err != nil {
-			return fmt.Errorf("failed to delete user: %w", err)
-		}
-
-		return nil
+		return services.DeleteUserAccount(tx, user)
 	})
 
 	if err != nil {
@@ -139,6 +125,8 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	services.WakeStorageOutbox()
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
@@ -183,34 +171,62 @@ func UploadAvatar(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file is received. Please upload an image with the key 'image'."})
-		return
-	}
-
 	// store old avatar to delete later
 	oldAvatar := user.AvatarURL
 
-	// upload new image
-	newURL, err := services.UploadImage(c.Request.Context(), file, &user, "avatars")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	var newURL string
+	var uploadID uint
+
+	// an image uploaded straight to storage is given by its upload ID
+	if value := c.PostForm("upload_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+			return
+		}
+		uploadID = uint(id)
+	} else {
+		file, err := c.FormFile("image")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No file is received. Please upload an image with the key 'image'."})
+			return
+		}
+
+		// upload new image
+		newURL, err = services.UploadImage(c.Request.Context(), file, &user, "avatars")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
-	// replace avatar with new image
-	if err := database.DB.Model(&user).Update("avatar_url", newURL).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if uploadID != 0 {
+			claimed, err := services.ClaimUploads(tx, user.ID, []uint{uploadID}, models.UploadPurposeAvatar)
+			if err != nil {
+				return err
+			}
+			newURL = claimed[0].URL
+		}
+
+		// replace avatar with new image
+		if err := tx.Model(&user).Update("avatar_url", newURL).Error; err != nil {
+			return err
+		}
+
+		// the old avatar is deleted once the new one is saved
+		return services.QueueImageDeletes(tx, []string{oldAvatar})
+	})
+	if errors.Is(err, services.ErrUploadRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar URL in database"})
 		return
 	}
 
-	// if user had avatar, delete it
-	if oldAvatar != "" {
-		if err := services.DeleteImageByURL(c.Request.Context(), oldAvatar); err != nil {
-			fmt.Printf("warning: failed to delete old avatar %s: %v\n", oldAvatar, err)
-		}
-	}
+	services.WakeStorageOutbox()
 
 	user.AvatarURL = newURL
 
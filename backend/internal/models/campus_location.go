@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type CampusLocationKind string
+
+const (
+	CampusBuilding CampusLocationKind = "building"
+	CampusDorm     CampusLocationKind = "dorm"
+	CampusOther    CampusLocationKind = "other"
+)
+
+func (k CampusLocationKind) Valid() bool {
+	switch k {
+	case CampusBuilding, CampusDorm, CampusOther:
+		return true
+	}
+	return false
+}
+
+// CampusLocation is a pickup point sellers can pick for their listings.
+// Inactive locations stay on old listings but can't be picked anymore.
+type CampusLocation struct {
+	ID         uint               `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	University string             `json:"university"`
+	Name       string             `json:"name"`
+	Kind       CampusLocationKind `json:"kind" gorm:"type:text;not null;default:building"`
+	Address    string             `json:"address"`
+	Latitude   float64            `json:"latitude"`
+	Longitude  float64            `json:"longitude"`
+	Active     bool               `json:"active" gorm:"default:true"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// DefaultCategoryLanguage is the language every category must have a name in
+const DefaultCategoryLanguage = "en"
+
+// LocalizedNames maps a language code to the category name in that language, stored as JSONB
+type LocalizedNames map[string]string
+
+func (n LocalizedNames) Value() (driver.Value, error) {
+	return json.Marshal(n)
+}
+
+func (n *LocalizedNames) Scan(value interface{}) error {
+	return scanJSON(value, n)
+}
+
+// Get returns the name in lang, falling back to the default language
+func (n LocalizedNames) Get(lang string) string {
+	if name, ok := n[lang]; ok && name != "" {
+		return name
+	}
+	return n[DefaultCategoryLanguage]
+}
+
+type Category struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	ParentID   *uint          `json:"parent_id"`
+	Slug       string         `json:"slug" gorm:"not null;unique"`
+	Names      LocalizedNames `json:"names" gorm:"type:jsonb"`
+	Icon       string         `json:"icon"`
+	SortOrder  int            `json:"sort_order"`
+	ExpiryDays *int           `json:"expiry_days"`
+}
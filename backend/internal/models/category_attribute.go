@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+	AttributeEnum    AttributeType = "enum"
+	// AttributeISBN is an ISBN-10 or ISBN-13, stored as digits only
+	AttributeISBN AttributeType = "isbn"
+)
+
+func (t AttributeType) Valid() bool {
+	switch t {
+	case AttributeText, AttributeNumber, AttributeBoolean, AttributeEnum, AttributeISBN:
+		return true
+	}
+	return false
+}
+
+// CategoryAttribute describes one structured field listings in a category can have.
+// Subcategories inherit the attributes of their parents.
+type CategoryAttribute struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	CategoryID uint           `json:"category_id"`
+	Key        string         `json:"key"`
+	Names      LocalizedNames `json:"names" gorm:"type:jsonb"`
+	Type       AttributeType  `json:"type" gorm:"type:text;not null"`
+	Required   bool           `json:"required"`
+	EnumValues pq.StringArray `json:"enum_values" gorm:"type:text[]"`
+	SortOrder  int            `json:"sort_order"`
+}
+
+// ListingAttributes holds a listing's attribute values by key, stored as JSONB
+type ListingAttributes map[string]any
+
+func (a ListingAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *ListingAttributes) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// BaseCurrency is the currency exchange rates are quoted against
+const BaseCurrency = "USD"
+
+// currencyMinorUnits lists the ISO 4217 currencies whose minor unit isn't a hundredth
+var currencyMinorUnits = map[string]int{
+	"BHD": 3,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// CurrencyMinorUnits returns how many decimal places the currency's minor unit has
+func CurrencyMinorUnits(currency string) int {
+	if units, ok := currencyMinorUnits[currency]; ok {
+		return units
+	}
+	return 2
+}
+
+// ToMinorUnits converts an amount in major units (dollars) to minor units (cents)
+func ToMinorUnits(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(CurrencyMinorUnits(currency))))
+}
+
+// FromMinorUnits converts an amount in minor units back to major units
+func FromMinorUnits(amount int64, currency string) float64 {
+	return float64(amount) / math.Pow10(CurrencyMinorUnits(currency))
+}
+
+// priceFromMinor sets a model's Price from its stored minor units, the models
+// call it after loading
+func priceFromMinor(price *float64, amount int64, currency string) {
+	*price = FromMinorUnits(amount, currency)
+}
+
+// ExchangeRate is how many units of Currency one BaseCurrency buys
+type ExchangeRate struct {
+	Currency  string    `json:"currency" gorm:"primaryKey"`
+	Rate      float64   `json:"rate" gorm:"type:numeric(24,10)"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
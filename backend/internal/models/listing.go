@@ -4,30 +4,47 @@ import (
 	"time"
 
 	"github.com/lib/pq"
-)
-
-type Category string
-
-const (
-	Electronics Category = "Electronics"
-	Furniture   Category = "Furniture"
-	Books       Category = "Books"
-	Clothing    Category = "Clothing"
-	Services    Category = "Services"
+	"gorm.io/gorm"
 )
 
 type Listing struct {
-	ID            uint              `json:"id" gorm:"primaryKey"`
-	CreatedAt     time.Time         `json:"created_at"`
-	UpdatedAt     time.Time         `json:"updated_at"`
-	UserID        uint              `json:"user_id"`
-	User          *User             `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
-	Title         string            `json:"title"`
-	Description   string            `json:"description"`
-	ImageURLs     pq.StringArray    `json:"image_urls" gorm:"type:text[]"`
-	Price         float64           `json:"price"`
-	Category      Category          `json:"category" gorm:"type:text;not null"`
-	IsClosed      bool              `json:"is_closed"`
-	WishlistedBy  []WishlistListing `gorm:"foreignKey:ListingID" json:"wishlisted_by,omitempty"`
-	AIPriceReport *AIPriceReport    `gorm:"foreignKey:ListingID" json:"ai_price_report,omitempty"`
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	UserID           uint              `json:"user_id"`
+	User             *User             `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Title            string            `json:"title"`
+	Description      string            `json:"description"`
+	ImageURLs        pq.StringArray    `json:"image_urls" gorm:"type:text[]"`
+	Images           []ListingImage    `json:"images,omitempty" gorm:"foreignKey:ListingID"`
+	PriceMinor       int64             `json:"price_minor"`
+	Currency         string            `json:"currency" gorm:"type:text;not null"`
+	Price            float64           `json:"price" gorm:"-"`
+	PriceBase        float64           `json:"-"`
+	Category         string            `json:"category" gorm:"type:text;not null"`
+	Attributes       ListingAttributes `json:"attributes" gorm:"type:jsonb;not null;default:'{}'"`
+	Condition        *ListingCondition `json:"condition" gorm:"type:text"`
+	PickupLocationID *uint             `json:"pickup_location_id"`
+	PickupLocation   *CampusLocation   `json:"pickup_location,omitempty" gorm:"foreignKey:PickupLocationID"`
+	Status           ListingStatus     `json:"status" gorm:"type:text;not null;default:active"`
+	PublishedAt      *time.Time        `json:"published_at,omitempty"`
+	ReservedAt       *time.Time        `json:"reserved_at,omitempty"`
+	SoldAt           *time.Time        `json:"sold_at,omitempty"`
+	ExpiredAt        *time.Time        `json:"expired_at,omitempty"`
+	ArchivedAt       *time.Time        `json:"archived_at,omitempty"`
+	RemovedAt        *time.Time        `json:"removed_at,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	ExpiryRemindedAt *time.Time        `json:"-"`
+	DeletedAt        gorm.DeletedAt    `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedBy        *ListingActor     `json:"deleted_by,omitempty" gorm:"type:text"`
+	ImagesPurgedAt   *time.Time        `json:"-"`
+	WishlistCount    int               `json:"wishlist_count" gorm:"default:0"`
+	WishlistedBy     []WishlistListing `gorm:"foreignKey:ListingID" json:"wishlisted_by,omitempty"`
+	AIPriceReport    *AIPriceReport    `gorm:"foreignKey:ListingID" json:"ai_price_report,omitempty"`
+}
+
+// AfterFind fills the asking price in major units from the stored minor units
+func (l *Listing) AfterFind(tx *gorm.DB) error {
+	priceFromMinor(&l.Price, l.PriceMinor, l.Currency)
+	return nil
 }
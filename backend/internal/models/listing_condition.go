@@ -0,0 +1,28 @@
+package models
+
+type ListingCondition string
+
+const (
+	ConditionNew      ListingCondition = "new"
+	ConditionLikeNew  ListingCondition = "like_new"
+	ConditionGood     ListingCondition = "good"
+	ConditionFair     ListingCondition = "fair"
+	ConditionForParts ListingCondition = "for_parts"
+)
+
+var ListingConditions = []ListingCondition{
+	ConditionNew,
+	ConditionLikeNew,
+	ConditionGood,
+	ConditionFair,
+	ConditionForParts,
+}
+
+func (c ListingCondition) Valid() bool {
+	for _, condition := range ListingConditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
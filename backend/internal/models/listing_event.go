@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type ListingEventType string
+
+const (
+	ListingEventView         ListingEventType = "view"
+	ListingEventWishlistAdd  ListingEventType = "wishlist_add"
+	ListingEventContactClick ListingEventType = "contact_click"
+)
+
+// ListingEvent is one tracked interaction with a listing. ViewerKey identifies
+// the viewer without storing who they are: a user ID or a hash of IP and user agent.
+// DedupBucket is the time window the event fell in, one event per viewer and window is kept.
+type ListingEvent struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ListingID   uint             `json:"listing_id"`
+	Type        ListingEventType `json:"type" gorm:"type:text;not null"`
+	ViewerKey   string           `json:"-"`
+	DedupBucket *int64           `json:"-"`
+}
+
+// ListingDailyStats is the rollup of a listing's events for one day
+type ListingDailyStats struct {
+	ListingID     uint      `json:"-" gorm:"primaryKey"`
+	Day           time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Views         int       `json:"views"`
+	UniqueViewers int       `json:"unique_viewers"`
+	WishlistAdds  int       `json:"wishlist_adds"`
+	ContactClicks int       `json:"contact_clicks"`
+}
+
+func (ListingDailyStats) TableName() string {
+	return "listing_daily_stats"
+}
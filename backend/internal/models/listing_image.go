@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ListingImage is one image of a listing. Listing.ImageURLs mirrors these for
+// clients that only need the URLs: the cover first, then the rest by position.
+// CardURL and ThumbURL are smaller copies, nil until the backfill makes them for
+// images uploaded before there were any, empty if it couldn't.
+type ListingImage struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ListingID   uint      `json:"listing_id"`
+	URL         string    `json:"url" gorm:"not null"`
+	CardURL     *string   `json:"card_url"`
+	ThumbURL    *string   `json:"thumb_url"`
+	Position    int       `json:"position"`
+	IsCover     bool      `json:"is_cover"`
+	AltText     string    `json:"alt_text"`
+	Width       *int      `json:"width"`
+	Height      *int      `json:"height"`
+	ByteSize    *int64    `json:"byte_size"`
+	ContentHash *string   `json:"content_hash"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type ListingImportStatus string
+
+const (
+	ListingImportPending    ListingImportStatus = "pending"
+	ListingImportProcessing ListingImportStatus = "processing"
+	ListingImportCompleted  ListingImportStatus = "completed"
+	ListingImportFailed     ListingImportStatus = "failed"
+)
+
+// ListingImport is a bulk import of listings from a CSV file. Completed imports
+// can still have failed rows, Failed means the file itself couldn't be processed.
+type ListingImport struct {
+	ID            uint                `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	UserID        uint                `json:"user_id"`
+	Status        ListingImportStatus `json:"status" gorm:"type:text;not null;default:pending"`
+	TotalRows     int                 `json:"total_rows"`
+	SucceededRows int                 `json:"succeeded_rows"`
+	FailedRows    int                 `json:"failed_rows"`
+	Error         string              `json:"error,omitempty"`
+	CompletedAt   *time.Time          `json:"completed_at,omitempty"`
+	Rows          []ListingImportRow  `json:"rows,omitempty" gorm:"foreignKey:ImportID"`
+}
+
+// ListingImportRow is the outcome of one CSV row: the created listing or why it failed
+type ListingImportRow struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ImportID  uint   `json:"-"`
+	Line      int    `json:"line"`
+	Title     string `json:"title"`
+	ListingID *uint  `json:"listing_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListingPrice is one entry of a listing's price history, added whenever the price changes
+type ListingPrice struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at"`
+	ListingID  uint      `json:"-"`
+	PriceMinor int64     `json:"price_minor"`
+	Currency   string    `json:"currency"`
+	Price      float64   `json:"price" gorm:"-"`
+	PriceBase  float64   `json:"-"`
+}
+
+// AfterFind fills the recorded price in major units, in the currency it was
+// recorded in
+func (p *ListingPrice) AfterFind(tx *gorm.DB) error {
+	priceFromMinor(&p.Price, p.PriceMinor, p.Currency)
+	return nil
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ListingSnapshot holds the editable fields of a listing at one revision, stored as JSONB
+type ListingSnapshot struct {
+	Title            string            `json:"title"`
+	Description      string            `json:"description"`
+	Price            float64           `json:"price"`
+	Currency         string            `json:"currency"`
+	Category         string            `json:"category"`
+	Attributes       ListingAttributes `json:"attributes"`
+	Condition        string            `json:"condition"`
+	PickupLocationID *uint             `json:"pickup_location_id"`
+	ImageURLs        []string          `json:"image_urls"`
+}
+
+func SnapshotListing(listing Listing) ListingSnapshot {
+	imageURLs := make([]string, len(listing.ImageURLs))
+	copy(imageURLs, listing.ImageURLs)
+
+	attributes := make(ListingAttributes, len(listing.Attributes))
+	for key, value := range listing.Attributes {
+		attributes[key] = value
+	}
+
+	snapshot := ListingSnapshot{
+		Title:       listing.Title,
+		Description: listing.Description,
+		Price:       listing.Price,
+		Currency:    listing.Currency,
+		Category:    listing.Category,
+		Attributes:  attributes,
+		ImageURLs:   imageURLs,
+	}
+	if listing.Condition != nil {
+		snapshot.Condition = string(*listing.Condition)
+	}
+	if listing.PickupLocationID != nil {
+		pickupLocationID := *listing.PickupLocationID
+		snapshot.PickupLocationID = &pickupLocationID
+	}
+
+	return snapshot
+}
+
+func (s ListingSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *ListingSnapshot) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// ListingChanges maps a field name to its old and new value, stored as JSONB
+type ListingChanges map[string]FieldChange
+
+func (c ListingChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *ListingChanges) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+// DiffListingSnapshots lists the fields that differ between two snapshots
+func DiffListingSnapshots(from, to ListingSnapshot) ListingChanges {
+	changes := ListingChanges{}
+
+	if from.Title != to.Title {
+		changes["title"] = FieldChange{From: from.Title, To: to.Title}
+	}
+	if from.Description != to.Description {
+		changes["description"] = FieldChange{From: from.Description, To: to.Description}
+	}
+	if from.Price != to.Price {
+		changes["price"] = FieldChange{From: from.Price, To: to.Price}
+	}
+	if from.Currency != to.Currency {
+		changes["currency"] = FieldChange{From: from.Currency, To: to.Currency}
+	}
+	if from.Category != to.Category {
+		changes["category"] = FieldChange{From: from.Category, To: to.Category}
+	}
+	if !reflect.DeepEqual(nonEmpty(from.Attributes), nonEmpty(to.Attributes)) {
+		changes["attributes"] = FieldChange{From: nonEmpty(from.Attributes), To: nonEmpty(to.Attributes)}
+	}
+	if from.Condition != to.Condition {
+		changes["condition"] = FieldChange{From: from.Condition, To: to.Condition}
+	}
+	if !reflect.DeepEqual(from.PickupLocationID, to.PickupLocationID) {
+		changes["pickup_location_id"] = FieldChange{From: from.PickupLocationID, To: to.PickupLocationID}
+	}
+	if !reflect.DeepEqual(nonNil(from.ImageURLs), nonNil(to.ImageURLs)) {
+		changes["image_urls"] = FieldChange{From: nonNil(from.ImageURLs), To: nonNil(to.ImageURLs)}
+	}
+
+	return changes
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func nonEmpty(attributes ListingAttributes) ListingAttributes {
+	if attributes == nil {
+		return ListingAttributes{}
+	}
+	return attributes
+}
+
+func scanJSON(value interface{}, dest any) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", value)
+	}
+}
+
+type ListingRevision struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time       `json:"created_at"`
+	ListingID uint            `json:"listing_id"`
+	Revision  int             `json:"revision"`
+	Actor     ListingActor    `json:"actor" gorm:"type:text;not null"`
+	ActorID   *uint           `json:"actor_id,omitempty"`
+	Changes   ListingChanges  `json:"changes" gorm:"type:jsonb"`
+	Snapshot  ListingSnapshot `json:"snapshot" gorm:"type:jsonb"`
+}
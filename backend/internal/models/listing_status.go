@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+type ListingStatus string
+
+const (
+	ListingDraft    ListingStatus = "draft"
+	ListingActive   ListingStatus = "active"
+	ListingReserved ListingStatus = "reserved"
+	ListingSold     ListingStatus = "sold"
+	ListingExpired  ListingStatus = "expired"
+	ListingArchived ListingStatus = "archived"
+	ListingRemoved  ListingStatus = "removed"
+)
+
+var ListingStatuses = []ListingStatus{
+	ListingDraft, ListingActive, ListingReserved, ListingSold, ListingExpired, ListingArchived, ListingRemoved,
+}
+
+// VisibleListingStatuses can be browsed and searched by anyone
+var VisibleListingStatuses = []ListingStatus{ListingActive, ListingReserved}
+
+// ViewableListingStatuses can still be opened by link, so buyers see a sold item as sold
+var ViewableListingStatuses = []ListingStatus{ListingActive, ListingReserved, ListingSold}
+
+// listingTransitions lists the statuses each status can move to
+var listingTransitions = map[ListingStatus][]ListingStatus{
+	ListingDraft:    {ListingActive, ListingArchived},
+	ListingActive:   {ListingReserved, ListingSold, ListingExpired, ListingArchived, ListingRemoved},
+	ListingReserved: {ListingActive, ListingSold, ListingArchived, ListingRemoved},
+	ListingSold:     {ListingActive, ListingArchived, ListingRemoved},
+	ListingExpired:  {ListingActive, ListingArchived, ListingRemoved},
+	ListingArchived: {ListingActive, ListingRemoved},
+	ListingRemoved:  {ListingActive},
+}
+
+func (s ListingStatus) Valid() bool {
+	_, ok := listingTransitions[s]
+	return ok
+}
+
+func (s ListingStatus) CanTransitionTo(next ListingStatus) bool {
+	for _, allowed := range listingTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsVisible reports whether the listing shows up in public lists and search
+func (s ListingStatus) IsVisible() bool {
+	for _, visible := range VisibleListingStatuses {
+		if s == visible {
+			return true
+		}
+	}
+	return false
+}
+
+// IsViewable reports whether anyone can open the listing by link
+func (s ListingStatus) IsViewable() bool {
+	for _, viewable := range ViewableListingStatuses {
+		if s == viewable {
+			return true
+		}
+	}
+	return false
+}
+
+type ListingActor string
+
+const (
+	ListingActorOwner  ListingActor = "owner"
+	ListingActorAdmin  ListingActor = "admin"
+	ListingActorSystem ListingActor = "system"
+)
+
+type ListingStatusTransition struct {
+	ID         uint          `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ListingID  uint          `json:"listing_id"`
+	FromStatus ListingStatus `json:"from_status" gorm:"type:text;not null"`
+	ToStatus   ListingStatus `json:"to_status" gorm:"type:text;not null"`
+	Actor      ListingActor  `json:"actor" gorm:"type:text;not null"`
+	ActorID    *uint         `json:"actor_id,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ListingTemplate is a seller's saved starting point for listings they post again
+// and again. It keeps its own copies of the images.
+type ListingTemplate struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	UserID           uint              `json:"user_id"`
+	Name             string            `json:"name" gorm:"not null"`
+	Title            string            `json:"title" gorm:"not null"`
+	Description      string            `json:"description"`
+	ImageURLs        pq.StringArray    `json:"image_urls" gorm:"type:text[]"`
+	PriceMinor       int64             `json:"price_minor"`
+	Currency         string            `json:"currency" gorm:"type:text;not null"`
+	Price            float64           `json:"price" gorm:"-"`
+	Category         string            `json:"category" gorm:"type:text;not null"`
+	Attributes       ListingAttributes `json:"attributes" gorm:"type:jsonb;not null;default:'{}'"`
+	Condition        *ListingCondition `json:"condition" gorm:"type:text"`
+	PickupLocationID *uint             `json:"pickup_location_id"`
+}
+
+// AfterFind fills the price the template prefills in major units
+func (t *ListingTemplate) AfterFind(tx *gorm.DB) error {
+	priceFromMinor(&t.Price, t.PriceMinor, t.Currency)
+	return nil
+}
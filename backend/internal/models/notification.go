@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationSavedSearchMatch  NotificationType = "saved_search_match"
+	NotificationSavedSearchDigest NotificationType = "saved_search_digest"
+	NotificationListingExpiring   NotificationType = "listing_expiring"
+	NotificationListingExpired    NotificationType = "listing_expired"
+	NotificationPriceDrop         NotificationType = "price_drop"
+	NotificationListingImport     NotificationType = "listing_import_finished"
+)
+
+type Notification struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time        `json:"created_at"`
+	UserID    uint             `json:"user_id"`
+	Type      NotificationType `json:"type" gorm:"type:text;not null"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body"`
+	ListingID *uint            `json:"listing_id,omitempty"`
+	ReadAt    *time.Time       `json:"read_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type SavedSearchFrequency string
+
+const (
+	SavedSearchInstant SavedSearchFrequency = "instant"
+	SavedSearchDaily   SavedSearchFrequency = "daily"
+)
+
+// SavedSearchParams is the subset of search filters that can be saved, stored as JSONB
+type SavedSearchParams struct {
+	Query      string   `json:"query,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	MinPrice   *float64 `json:"min_price,omitempty"`
+	MaxPrice   *float64 `json:"max_price,omitempty"`
+	Currency   string   `json:"currency,omitempty"`
+	University string   `json:"university,omitempty"`
+	MinRating  *float64 `json:"min_rating,omitempty"`
+	HasImages  *bool    `json:"has_images,omitempty"`
+	Lang       string   `json:"lang,omitempty"`
+}
+
+func (p SavedSearchParams) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *SavedSearchParams) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case nil:
+		*p = SavedSearchParams{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported saved search params type %T", value)
+	}
+}
+
+type SavedSearch struct {
+	ID           uint                 `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	UserID       uint                 `json:"user_id"`
+	Name         string               `json:"name"`
+	Params       SavedSearchParams    `json:"params" gorm:"type:jsonb"`
+	Frequency    SavedSearchFrequency `json:"frequency" gorm:"type:text;not null;default:instant"`
+	LastDigestAt *time.Time           `json:"last_digest_at,omitempty"`
+}
+
+type SavedSearchMatch struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SavedSearchID uint       `json:"saved_search_id"`
+	ListingID     uint       `json:"listing_id"`
+	Listing       *Listing   `json:"listing,omitempty" gorm:"foreignKey:ListingID"`
+	NotifiedAt    *time.Time `json:"notified_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type StorageGCStatus string
+
+const (
+	StorageGCRunning   StorageGCStatus = "running"
+	StorageGCCompleted StorageGCStatus = "completed"
+	StorageGCFailed    StorageGCStatus = "failed"
+)
+
+// StorageGCRun is the report of one pass of the orphaned image collector.
+// Recent objects are younger than the grace period and were left alone, a dry
+// run finds orphans without deleting them. OrphanKeys is capped, the counts aren't.
+type StorageGCRun struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Status           StorageGCStatus `json:"status" gorm:"type:text;not null;default:running"`
+	DryRun           bool            `json:"dry_run"`
+	GracePeriodHours int             `json:"grace_period_hours"`
+	ScannedObjects   int             `json:"scanned_objects"`
+	ScannedBytes     int64           `json:"scanned_bytes"`
+	RecentObjects    int             `json:"recent_objects"`
+	OrphanedObjects  int             `json:"orphaned_objects"`
+	OrphanedBytes    int64           `json:"orphaned_bytes"`
+	DeletedObjects   int             `json:"deleted_objects"`
+	DeletedBytes     int64           `json:"deleted_bytes"`
+	FailedDeletes    int             `json:"failed_deletes"`
+	OrphanKeys       pq.StringArray  `json:"orphan_keys" gorm:"type:text[]"`
+	Error            string          `json:"error,omitempty"`
+	FinishedAt       *time.Time      `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type StorageOperation string
+
+const (
+	// StorageDeleteImage deletes an image by its public URL, with its variants
+	StorageDeleteImage StorageOperation = "delete_image"
+	// StorageDeleteObject deletes a single object by its key
+	StorageDeleteObject StorageOperation = "delete_object"
+)
+
+type StorageOutboxStatus string
+
+const (
+	StorageOutboxPending StorageOutboxStatus = "pending"
+	StorageOutboxDead    StorageOutboxStatus = "dead"
+)
+
+// StorageOutboxEntry is a storage operation waiting for the transaction that
+// queued it to commit. Done entries are removed, dead ones ran out of attempts
+// and wait for an admin to retry or discard them.
+type StorageOutboxEntry struct {
+	ID            uint                `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Operation     StorageOperation    `json:"operation" gorm:"type:text;not null"`
+	Target        string              `json:"target" gorm:"not null"`
+	Status        StorageOutboxStatus `json:"status" gorm:"type:text;not null;default:pending"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	LastError     string              `json:"last_error,omitempty"`
+	DeadAt        *time.Time          `json:"dead_at,omitempty"`
+}
+
+func (StorageOutboxEntry) TableName() string {
+	return "storage_outbox"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type UploadPurpose string
+
+const (
+	UploadPurposeListing UploadPurpose = "listing"
+	UploadPurposeAvatar  UploadPurpose = "avatar"
+)
+
+type UploadStatus string
+
+const (
+	UploadPending   UploadStatus = "pending"
+	UploadConfirmed UploadStatus = "confirmed"
+)
+
+// Upload is a file the client sends straight to storage with a presigned URL.
+// Confirming checks it against what was announced and stores it as an image,
+// attaching the image to a listing or avatar removes the upload.
+type Upload struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	UserID      uint          `json:"-"`
+	Purpose     UploadPurpose `json:"purpose" gorm:"type:text;not null"`
+	Status      UploadStatus  `json:"status" gorm:"type:text;not null;default:pending"`
+	Key         string        `json:"-" gorm:"not null"`
+	ContentType string        `json:"content_type" gorm:"not null"`
+	ByteSize    int64         `json:"byte_size"`
+	ContentHash string        `json:"content_hash" gorm:"not null"`
+	// pending uploads are removed after this, confirmed ones are given longer
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+
+	// the stored image, set once confirmed
+	ImageURL         *string `json:"image_url,omitempty"`
+	CardURL          *string `json:"card_url,omitempty"`
+	ThumbURL         *string `json:"thumb_url,omitempty"`
+	Width            *int    `json:"width,omitempty"`
+	Height           *int    `json:"height,omitempty"`
+	ImageByteSize    *int64  `json:"image_byte_size,omitempty"`
+	ImageContentHash *string `json:"image_content_hash,omitempty"`
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRates maps a currency code to how many units of it one BaseCurrency buys
+type ExchangeRates map[string]float64
+
+func LoadExchangeRates() (ExchangeRates, error) {
+	var rows []models.ExchangeRate
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
+	rates := make(ExchangeRates, len(rows))
+	for _, row := range rows {
+		rates[row.Currency] = row.Rate
+	}
+	return rates, nil
+}
+
+// Convert converts an amount in major units, rounded to the target currency's minor unit
+func (r ExchangeRates) Convert(amount float64, from string, to string) (float64, bool) {
+	fromRate, ok := r[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := r[to]
+	if !ok {
+		return 0, false
+	}
+
+	scale := math.Pow10(models.CurrencyMinorUnits(to))
+	return math.Round(amount/fromRate*toRate*scale) / scale, true
+}
+
+// SetListingPrice stores amount (in major units) and its currency on the listing,
+// together with the base currency price used to compare listings
+func SetListingPrice(tx *gorm.DB, listing *models.Listing, amount float64, currency string) error {
+	var rate models.ExchangeRate
+	if err := tx.First(&rate, "currency = ?", currency).Error; err != nil {
+		return fmt.Errorf("unsupported currency %s", currency)
+	}
+
+	listing.Currency = currency
+	listing.PriceMinor = models.ToMinorUnits(amount, currency)
+	listing.Price = models.FromMinorUnits(listing.PriceMinor, currency)
+	listing.PriceBase = listing.Price / rate.Rate
+	return nil
+}
+
+// SaveExchangeRates inserts or updates rates and reprices the listings in those
+// currencies, so sorting and price filters follow the new rates right away
+func SaveExchangeRates(tx *gorm.DB, rates ExchangeRates, source string) error {
+	now := time.Now()
+	for currency, rate := range rates {
+		row := models.ExchangeRate{
+			Currency:  currency,
+			Rate:      rate,
+			Source:    source,
+			UpdatedAt: now,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+		}).Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to save %s rate: %w", currency, err)
+		}
+
+		if err := tx.Unscoped().Model(&models.Listing{}).
+			Where("currency = ?", currency).
+			UpdateColumn("price_base", gorm.Expr("price_minor::double precision / ? / ?", math.Pow10(models.CurrencyMinorUnits(currency)), rate)).
+			Error; err != nil {
+			return fmt.Errorf("failed to reprice %s listings: %w", currency, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"io"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// how long a finished archive is kept in storage
+	DataExportRetention = 7 * 24 * time.Hour
+	// how long a single download link stays valid
+	DataExportLinkTTL = time.Hour
+	// exports still pending after this long were interrupted by a restart
+	dataExportStaleAfter = time.Hour
+)
+
+const dataExportReadme = `This archive contains the personal data stored about your account.
+
+profile.json            your profile
+listings.json           listings you created
+ai_price_reports.json   AI price reports generated for your listings
+wishlist.json           listings you saved to your wishlist
+ratings_given.json      ratings you gave to other sellers
+ratings_received.json   ratings other users gave you
+images/                 original images you uploaded
+
+Sessions: sign-in uses stateless tokens kept only in your browser,
+so no session records are stored on our servers.
+`
+
+type exportedWishlistItem struct {
+	ListingID uint      `json:"listing_id"`
+	Title     string    `json:"title"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// BuildDataExport collects everything stored about the export's owner into a
+// ZIP archive and uploads it to private storage. Meant to run in the background.
+func BuildDataExport(ctx context.Context, exportID uint) {
+	var export models.DataExport
+	if err := database.DB.First(&export, exportID).Error; err != nil {
+		log.Printf("data export %d: %v", exportID, err)
+		return
+	}
+
+	database.DB.Model(&export).Update("status", models.DataExportProcessing)
+
+	key, err := writeDataExport(ctx, export.UserID)
+	if err != nil {
+		log.Printf("data export %d failed: %v", exportID, err)
+		database.DB.Model(&export).Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	database.DB.Model(&export).Updates(map[string]interface{}{
+		"status":       models.DataExportReady,
+		"object_key":   key,
+		"completed_at": now,
+		"expires_at":   now.Add(DataExportRetention),
+	})
+}
+
+func writeDataExport(ctx context.Context, userID uint) (string, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return "", fmt.Errorf("failed to load user: %w", err)
+	}
+
+	// deleted listings are still stored until purged, so they are exported too
+	var listings []models.Listing
+	if err := database.DB.Unscoped().Where("user_id = ?", userID).Order("id").Find(&listings).Error; err != nil {
+		return "", fmt.Errorf("failed to load listings: %w", err)
+	}
+
+	var reports []models.AIPriceReport
+	if err := database.DB.
+		Joins("JOIN listings ON listings.id = ai_price_reports.listing_id").
+		Where("listings.user_id = ?", userID).
+		Find(&reports).Error; err != nil {
+		return "", fmt.Errorf("failed to load AI price reports: %w", err)
+	}
+
+	var wishlist []exportedWishlistItem
+	if err := database.DB.Table("wishlist_listings").
+		Select("wishlist_listings.listing_id, listings.title, wishlist_listings.created_at AS added_at").
+		Joins("JOIN listings ON listings.id = wishlist_listings.listing_id").
+		Where("wishlist_listings.user_id = ?", userID).
+		Order("wishlist_listings.created_at").
+		Scan(&wishlist).Error; err != nil {
+		return "", fmt.Errorf("failed to load wishlist: %w", err)
+	}
+
+	var ratingsGiven []models.Rating
+	if err := database.DB.Where("rater_id = ?", userID).Order("created_at").Find(&ratingsGiven).Error; err != nil {
+		return "", fmt.Errorf("failed to load ratings given: %w", err)
+	}
+
+	var ratingsReceived []models.Rating
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&ratingsReceived).Error; err != nil {
+		return "", fmt.Errorf("failed to load ratings received: %w", err)
+	}
+
+	// build the archive on disk, images can make it large
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+
+	entries := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", user},
+		{"listings.json", listings},
+		{"ai_price_reports.json", reports},
+		{"wishlist.json", wishlist},
+		{"ratings_given.json", ratingsGiven},
+		{"ratings_received.json", ratingsReceived},
+	}
+	for _, entry := range entries {
+		if err := writeZipJSON(zw, entry.name, entry.data); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writeZipFile(zw, "README.txt", []byte(dataExportReadme)); err != nil {
+		return "", err
+	}
+
+	// original images, a missing object should not fail the whole export
+	for _, listing := range listings {
+		for _, imgURL := range listing.ImageURLs {
+			name := fmt.Sprintf("images/listings/%d/%s", listing.ID, path.Base(imgURL))
+			if err := copyImageToZip(ctx, zw, name, imgURL); err != nil {
+				log.Printf("warning: data export skipped image %s: %v", imgURL, err)
+			}
+		}
+	}
+
+	if user.AvatarURL != "" {
+		name := "images/avatar/" + path.Base(user.AvatarURL)
+		if err := copyImageToZip(ctx, zw, name, user.AvatarURL); err != nil {
+			log.Printf("warning: data export skipped avatar %s: %v", user.AvatarURL, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	key := fmt.Sprintf("exports/%d/%s.zip", userID, uuid.NewString())
+	if err := PutObject(ctx, key, file, "application/zip"); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, data any) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	return writeZipFile(zw, name, content)
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func copyImageToZip(ctx context.Context, zw *zip.Writer, name string, imageURL string) error {
+	data, _, err := GetImageByURL(ctx, imageURL)
+	if err != nil {
+		return err
+	}
+
+	return writeZipFile(zw, name, data)
+}
+
+// RunDataExportCleanup periodically deletes archives past their expiry and
+// fails exports that were interrupted by a restart.
+func RunDataExportCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cleanupDataExports()
+		<-ticker.C
+	}
+}
+
+func cleanupDataExports() {
+	database.DB.Model(&models.DataExport{}).
+		Where("status IN ? AND updated_at < ?",
+			[]models.DataExportStatus{models.DataExportPending, models.DataExportProcessing},
+			time.Now().Add(-dataExportStaleAfter)).
+		Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  "export was interrupted, please request a new one",
+		})
+
+	var expired []models.DataExport
+	if err := database.DB.
+		Where("status = ? AND expires_at < ?", models.DataExportReady, time.Now()).
+		Find(&expired).Error; err != nil {
+		log.Printf("warning: failed to fetch expired data exports: %v", err)
+		return
+	}
+
+	for _, export := range expired {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&export).Updates(map[string]interface{}{
+				"status":     models.DataExportExpired,
+				"object_key": "",
+			}).Error; err != nil {
+				return err
+			}
+			return QueueObjectDeletes(tx, []string{export.ObjectKey})
+		})
+		if err != nil {
+			log.Printf("warning: failed to expire data export %d: %v", export.ID, err)
+		}
+	}
+
+	WakeStorageOutbox()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"gin-backend/internal/models"
 	"io"
 	"log"
 	"mime"
@@ -42,30 +43,49 @@ type PriceSuggestionResponse struct {
 	Reasoning         string  `json:"reasoning"`
 }
 
-func SuggestPrice(ctx context.Context, title string, language string, description string, images []*multipart.FileHeader, imageURLs []string) (PriceSuggestionResponse, error) {
+// conditionPrompts describes each condition to the model in the terms resale markets use
+var conditionPrompts = map[models.ListingCondition]string{
+	models.ConditionNew:      "new, unused, possibly in original packaging",
+	models.ConditionLikeNew:  "like new, used briefly with no visible wear",
+	models.ConditionGood:     "good, used with minor signs of wear, fully working",
+	models.ConditionFair:     "fair, noticeable wear or cosmetic damage, still working",
+	models.ConditionForParts: "for parts, broken or not fully working",
+}
+
+// SuggestPrice asks Gemini for a price range in currency. When the seller states the
+// condition it is given to the model as a fact instead of being guessed from the photos.
+func SuggestPrice(ctx context.Context, title string, language string, description string, condition models.ListingCondition, currency string, images []*multipart.FileHeader, imageURLs []string) (PriceSuggestionResponse, error) {
+	conditionStep := "Assess the condition and quality (implied from images/description)."
+	conditionLine := "not stated"
+	if prompt, ok := conditionPrompts[condition]; ok {
+		conditionStep = "Take the condition stated by the seller as given, only use the images to assess quality and completeness."
+		conditionLine = prompt
+	}
+
 	// create text prompt
 	parts := []*genai.Part{
 		{Text: fmt.Sprintf(
 			`Analyze this listing and predict a fair market price based on the following information:
 			Title: %s
 			Description: %s
+			Condition: %s
 
 			Based on the images provided and the description, please perform a brief and highly targeted analysis.
 
-			1. Assess the condition and quality (implied from images/description).
+			1. %s
 			2. Consider market demand and prices of comparable items.
-			3. Provide a price range with justification.
+			3. Provide a price range in %s with justification.
 
 			Please respond **strictly in JSON format**. Ensure the output is **maximal clarity with short length**.
 
 			{
 				"suggested_price_min": "<The lowest fair selling price as a numeric value.>",
 				"suggested_price_max": "<The highest fair selling price as a numeric value.>",
-				"currency": "<The currency used for the prices (e.g., USD).>",
+				"currency": "%s",
 				"confidence_level": "<Assessment of prediction certainty: 'high' (complete information, clear comps), 'medium' (average information), or 'low' (missing images/details, volatile market).>",
 				"reasoning": "<A single, concise sentence (max 50 words) summarizing the 2-3 **primary factors** that directly drove the suggested price range.>"
 			}`,
-			title, description)},
+			title, description, conditionLine, conditionStep, currency, currency)},
 	}
 
 	if len(images) > 0 {
@@ -146,5 +166,8 @@ func SuggestPrice(ctx context.Context, title string, language string, descriptio
 		return PriceSuggestionResponse{}, err
 	}
 
+	// the range was asked for in currency, don't trust the model to echo it back
+	priceSuggestionResponse.Currency = currency
+
 	return priceSuggestionResponse, nil
 }
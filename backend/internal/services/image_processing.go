@@ -0,0 +1,258 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// larger images are refused before decoding, a small file can still claim
+	// enormous dimensions
+	maxImagePixels = 50_000_000
+	// variants are JPEG: x/image only decodes WebP and the lossless pure Go WebP
+	// encoders produce larger files than this for photos
+	imageVariantQuality = 82
+)
+
+// ImageVariant is a resized copy made of every upload. The full variant is
+// stored under the image's own URL, the others next to it.
+type ImageVariant struct {
+	Name    string
+	MaxSide int
+}
+
+var (
+	ImageVariantFull  = ImageVariant{Name: "full", MaxSide: 1600}
+	ImageVariantCard  = ImageVariant{Name: "card", MaxSide: 640}
+	ImageVariantThumb = ImageVariant{Name: "thumb", MaxSide: 240}
+
+	imageVariants = []ImageVariant{ImageVariantFull, ImageVariantCard, ImageVariantThumb}
+)
+
+var errImageTooLarge = errors.New("image dimensions are too large")
+
+// processedImage is an encoded variant ready to be stored
+type processedImage struct {
+	variant ImageVariant
+	data    []byte
+	width   int
+	height  int
+}
+
+// processImage decodes an upload, turns it upright and re-encodes it as every
+// variant. Re-encoding drops all metadata, so EXIF data like the GPS position
+// never reaches storage.
+func processImage(data []byte) ([]processedImage, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image format: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, errImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// the limit is on the longer side, which orientation doesn't change, so the
+	// image is scaled down before being rotated
+	full := orientImage(resizeImage(src, ImageVariantFull.MaxSide), jpegOrientation(data))
+
+	var processed []processedImage
+	for _, variant := range imageVariants {
+		img := full
+		if variant != ImageVariantFull {
+			img = resizeImage(full, variant.MaxSide)
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: imageVariantQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s image: %w", variant.Name, err)
+		}
+
+		bounds := img.Bounds()
+		processed = append(processed, processedImage{
+			variant: variant,
+			data:    buf.Bytes(),
+			width:   bounds.Dx(),
+			height:  bounds.Dy(),
+		})
+	}
+
+	return processed, nil
+}
+
+// resizeImage scales img down so its longer side is at most maxSide, onto a
+// white background since JPEG has no transparency. Smaller images keep their size.
+func resizeImage(img image.Image, maxSide int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			height = max(1, height*maxSide/width)
+			width = maxSide
+		} else {
+			width = max(1, width*maxSide/height)
+			height = maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// orientImage applies an EXIF orientation, 1 being upright
+func orientImage(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// orientations 5 to 8 are rotated by 90 degrees and swap the sides
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // flip horizontally
+				dx, dy = width-1-x, y
+			case 3: // turn half way
+				dx, dy = width-1-x, height-1-y
+			case 4: // flip vertically
+				dx, dy = x, height-1-y
+			case 5: // flip along the diagonal
+				dx, dy = y, x
+			case 6: // turn clockwise
+				dx, dy = height-1-y, x
+			case 7: // flip along the other diagonal
+				dx, dy = height-1-y, width-1-x
+			case 8: // turn counterclockwise
+				dx, dy = y, width-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation reads the orientation tag from a JPEG's EXIF data, 1 when
+// there is none or the image isn't a JPEG
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// walk the segments up to the image data looking for the EXIF one
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// start of scan, the metadata segments are all before it
+		if marker == 0xDA {
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+
+	return 1
+}
+
+// exifOrientation finds the orientation tag in the first IFD of a TIFF header
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// tag 0x0112 is a SHORT stored inline in the value field
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 1
+}
+
+// imageVariantKey is where a variant of the image stored under key lives. The
+// full variant is the image itself.
+func imageVariantKey(key string, variant ImageVariant) string {
+	if variant == ImageVariantFull {
+		return key
+	}
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + variant.Name + ".jpg"
+}
+
+// ImageVariantURL is the public URL of a variant of an image
+func ImageVariantURL(imageURL string, variant ImageVariant) string {
+	key, err := imageKey(imageURL)
+	if err != nil {
+		return imageURL
+	}
+	return publicImageURL(imageVariantKey(key, variant))
+}
+
+// readAllSeeker reads an upload from the start
+func readAllSeeker(src io.ReadSeeker) ([]byte, error) {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to reset file stream: %w", err)
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return data, nil
+}
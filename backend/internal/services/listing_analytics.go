@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// repeat events of one type from the same viewer within one window of this length count once
+	ListingEventDedupWindow = 30 * time.Minute
+	// raw events are only needed for deduplication and rollups
+	listingEventRetention = 7 * 24 * time.Hour
+	// days rolled up on each run, covers events that arrived around midnight
+	listingStatsRollupDays = 2
+)
+
+var botUserAgentPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|preview|facebookexternalhit|headless|curl|wget|python-requests|go-http-client|httpclient|java/`)
+
+// IsBot reports whether a user agent looks automated, an empty one counts as a bot
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botUserAgentPattern.MatchString(userAgent)
+}
+
+// TrackListingEvent records an interaction with a listing. Time is split into
+// windows of ListingEventDedupWindow and only the first event of a type from a
+// viewer in each window is kept, so toggling a listing in and out of the wishlist
+// counts as one add. A unique index decides, concurrent requests count once too.
+func TrackListingEvent(listingID uint, eventType models.ListingEventType, viewerKey string) error {
+	bucket := time.Now().Unix() / int64(ListingEventDedupWindow/time.Second)
+	event := models.ListingEvent{
+		ListingID:   listingID,
+		Type:        eventType,
+		ViewerKey:   viewerKey,
+		DedupBucket: &bucket,
+	}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record listing event: %w", err)
+	}
+
+	return nil
+}
+
+// RunListingStatsRollup periodically folds raw listing events into daily stats
+// and drops events that are no longer needed
+func RunListingStatsRollup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rollupListingStats()
+		pruneListingEvents()
+		<-ticker.C
+	}
+}
+
+// rollupListingStats recomputes the stats of the last days from the raw events,
+// running it again for the same days gives the same result
+func rollupListingStats() {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day()-(listingStatsRollupDays-1), 0, 0, 0, 0, now.Location())
+
+	err := database.DB.Exec(`
+		INSERT INTO listing_daily_stats (listing_id, day, views, unique_viewers, wishlist_adds, contact_clicks)
+		SELECT
+			listing_id,
+			created_at::date,
+			COUNT(*) FILTER (WHERE type = 'view'),
+			COUNT(DISTINCT viewer_key) FILTER (WHERE type = 'view'),
+			COUNT(*) FILTER (WHERE type = 'wishlist_add'),
+			COUNT(*) FILTER (WHERE type = 'contact_click')
+		FROM listing_events
+		WHERE created_at >= ?
+		GROUP BY listing_id, created_at::date
+		ON CONFLICT (listing_id, day) DO UPDATE SET
+			views = EXCLUDED.views,
+			unique_viewers = EXCLUDED.unique_viewers,
+			wishlist_adds = EXCLUDED.wishlist_adds,
+			contact_clicks = EXCLUDED.contact_clicks`,
+		since,
+	).Error
+	if err != nil {
+		log.Printf("warning: failed to roll up listing stats: %v", err)
+	}
+}
+
+func pruneListingEvents() {
+	if err := database.DB.
+		Where("created_at < ?", time.Now().Add(-listingEventRetention)).
+		Delete(&models.ListingEvent{}).Error; err != nil {
+		log.Printf("warning: failed to prune listing events: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListingExpiryDays = 90
+	// how long before expiry the owner is reminded to renew
+	ListingExpiryReminderLead = 3 * 24 * time.Hour
+)
+
+// ListingExpiryPeriod returns how long a listing in the category stays active after publishing.
+// The period comes from the closest category up the tree that sets expiry_days,
+// then from LISTING_EXPIRY_DAYS, then from the built-in default.
+func ListingExpiryPeriod(category string) time.Duration {
+	var days []int
+	if err := database.DB.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, expiry_days, 0 AS depth FROM categories WHERE slug = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, c.expiry_days, a.depth + 1
+			FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT expiry_days FROM ancestors WHERE expiry_days IS NOT NULL ORDER BY depth LIMIT 1`,
+		category,
+	).Scan(&days).Error; err != nil {
+		log.Printf("warning: failed to look up expiry period of %s: %v", category, err)
+	}
+	if len(days) > 0 {
+		return time.Duration(days[0]) * 24 * time.Hour
+	}
+
+	if value := os.Getenv("LISTING_EXPIRY_DAYS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	}
+
+	return defaultListingExpiryDays * 24 * time.Hour
+}
+
+// RenewListing restarts the listing's expiry period, reactivating it if it already expired
+func RenewListing(tx *gorm.DB, listing *models.Listing, actorID *uint) error {
+	if listing.Status == models.ListingExpired {
+		return TransitionListing(tx, listing, models.ListingActive, models.ListingActorOwner, actorID, "renewed")
+	}
+
+	if listing.Status != models.ListingActive {
+		return fmt.Errorf("only active or expired listings can be renewed")
+	}
+
+	expiresAt := time.Now().Add(ListingExpiryPeriod(listing.Category))
+	if err := tx.Model(listing).Updates(map[string]interface{}{
+		"expires_at":         expiresAt,
+		"expiry_reminded_at": nil,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to renew listing: %w", err)
+	}
+
+	listing.ExpiresAt = &expiresAt
+	listing.ExpiryRemindedAt = nil
+	return nil
+}
+
+// RunListingExpiry periodically reminds owners of listings about to expire
+// and moves listings past their expiry date to expired
+func RunListingExpiry(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		remindExpiringListings()
+		expireListings()
+		<-ticker.C
+	}
+}
+
+func remindExpiringListings() {
+	var listings []models.Listing
+	if err := database.DB.
+		Where("status = ? AND expiry_reminded_at IS NULL", models.ListingActive).
+		Where("expires_at > ? AND expires_at <= ?", time.Now(), time.Now().Add(ListingExpiryReminderLead)).
+		Find(&listings).Error; err != nil {
+		log.Printf("warning: failed to fetch expiring listings: %v", err)
+		return
+	}
+
+	for _, listing := range listings {
+		listingID := listing.ID
+		notification := models.Notification{
+			UserID:    listing.UserID,
+			Type:      models.NotificationListingExpiring,
+			Title:     fmt.Sprintf("\"%s\" expires soon", listing.Title),
+			Body:      fmt.Sprintf("Your listing expires on %s. Renew it to keep it visible.", listing.ExpiresAt.Format("January 2")),
+			ListingID: &listingID,
+		}
+		if err := Notify(notification, true); err != nil {
+			log.Printf("warning: %v", err)
+			continue
+		}
+
+		database.DB.Model(&listing).Update("expiry_reminded_at", time.Now())
+	}
+}
+
+func expireListings() {
+	var listings []models.Listing
+	if err := database.DB.
+		Where("status = ? AND expires_at <= ?", models.ListingActive, time.Now()).
+		Find(&listings).Error; err != nil {
+		log.Printf("warning: failed to fetch expired listings: %v", err)
+		return
+	}
+
+	for _, listing := range listings {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return TransitionListing(tx, &listing, models.ListingExpired, models.ListingActorSystem, nil, "expiry period ended")
+		})
+		if err != nil {
+			log.Printf("warning: listing %d not expired: %v", listing.ID, err)
+			continue
+		}
+
+		listingID := listing.ID
+		notification := models.Notification{
+			UserID:    listing.UserID,
+			Type:      models.NotificationListingExpired,
+			Title:     fmt.Sprintf("\"%s\" has expired", listing.Title),
+			Body:      "Your listing is no longer shown to buyers. Renew it from your dashboard to publish it again.",
+			ListingID: &listingID,
+		}
+		if err := Notify(notification, true); err != nil {
+			log.Printf("warning: %v", err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// images whose metadata is filled in on each backfill run
+const listingImageBackfillBatch = 20
+
+// ListingImage returns the image as a listing image, position and cover are set
+// when it's added to a listing
+func (s StoredImage) ListingImage() models.ListingImage {
+	image := models.ListingImage{URL: s.URL}
+	if s.ContentHash != "" {
+		image.ByteSize = &s.ByteSize
+		image.ContentHash = &s.ContentHash
+	}
+	if s.Width > 0 && s.Height > 0 {
+		image.Width = &s.Width
+		image.Height = &s.Height
+	}
+	if s.CardURL != "" && s.ThumbURL != "" {
+		image.CardURL = &s.CardURL
+		image.ThumbURL = &s.ThumbURL
+	}
+	return image
+}
+
+// StoredListingImages converts uploads to listing images, in the same order
+func StoredListingImages(stored []StoredImage) []models.ListingImage {
+	images := make([]models.ListingImage, len(stored))
+	for i, s := range stored {
+		images[i] = s.ListingImage()
+	}
+	return images
+}
+
+// ListingImagesFromURLs is for images nothing is known about yet, the backfill
+// fills in their metadata later
+func ListingImagesFromURLs(urls []string) []models.ListingImage {
+	images := make([]models.ListingImage, len(urls))
+	for i, url := range urls {
+		images[i] = models.ListingImage{URL: url}
+	}
+	return images
+}
+
+// SetListingImages makes images the listing's images, in this order. Images the
+// listing already has keep their metadata and alt text, new ones are added as
+// given. The cover is the image flagged IsCover, else the current cover if it's
+// still there, else the first image. Run it in the caller's transaction.
+func SetListingImages(tx *gorm.DB, listing *models.Listing, images []models.ListingImage) error {
+	var existing []models.ListingImage
+	if err := tx.Where("listing_id = ?", listing.ID).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to fetch listing images: %w", err)
+	}
+
+	byURL := make(map[string]models.ListingImage, len(existing))
+	coverURL := ""
+	for _, image := range existing {
+		byURL[image.URL] = image
+		if image.IsCover {
+			coverURL = image.URL
+		}
+	}
+
+	// the same URL twice would break the unique constraint, the first one wins
+	var urls []string
+	kept := make(map[string]bool, len(images))
+	unique := images[:0:0]
+	for _, image := range images {
+		if kept[image.URL] {
+			continue
+		}
+		kept[image.URL] = true
+		urls = append(urls, image.URL)
+		unique = append(unique, image)
+		if image.IsCover {
+			coverURL = image.URL
+		}
+	}
+	if !kept[coverURL] {
+		coverURL = ""
+		if len(urls) > 0 {
+			coverURL = urls[0]
+		}
+	}
+
+	removed := tx.Where("listing_id = ?", listing.ID)
+	if len(urls) > 0 {
+		removed = removed.Where("url NOT IN ?", urls)
+	}
+	if err := removed.Delete(&models.ListingImage{}).Error; err != nil {
+		return fmt.Errorf("failed to remove listing images: %w", err)
+	}
+
+	// the cover index isn't deferrable, so the cover is cleared first and set last
+	if err := tx.Model(&models.ListingImage{}).
+		Where("listing_id = ? AND is_cover", listing.ID).
+		Update("is_cover", false).Error; err != nil {
+		return fmt.Errorf("failed to update listing images: %w", err)
+	}
+
+	result := make([]models.ListingImage, 0, len(unique))
+	for position, image := range unique {
+		if current, ok := byURL[image.URL]; ok {
+			if err := tx.Model(&current).Update("position", position).Error; err != nil {
+				return fmt.Errorf("failed to update listing images: %w", err)
+			}
+			image = current
+		} else {
+			image.ID = 0
+			image.ListingID = listing.ID
+			image.Position = position
+			image.IsCover = false
+			if err := tx.Create(&image).Error; err != nil {
+				return fmt.Errorf("failed to add listing image: %w", err)
+			}
+		}
+		image.IsCover = image.URL == coverURL
+		result = append(result, image)
+	}
+
+	if coverURL != "" {
+		if err := tx.Model(&models.ListingImage{}).
+			Where("listing_id = ? AND url = ?", listing.ID, coverURL).
+			Update("is_cover", true).Error; err != nil {
+			return fmt.Errorf("failed to set cover image: %w", err)
+		}
+	}
+
+	listing.Images = result
+	listing.ImageURLs = ListingImageURLs(result)
+	if err := tx.Model(listing).UpdateColumn("image_urls", listing.ImageURLs).Error; err != nil {
+		return fmt.Errorf("failed to update listing image URLs: %w", err)
+	}
+
+	return nil
+}
+
+// ListingImageURLs orders image URLs the way Listing.ImageURLs has them: the
+// cover first, then the rest by position
+func ListingImageURLs(images []models.ListingImage) pq.StringArray {
+	sorted := make([]models.ListingImage, len(images))
+	copy(sorted, images)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].IsCover != sorted[j].IsCover {
+			return sorted[i].IsCover
+		}
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	urls := make(pq.StringArray, len(sorted))
+	for i, image := range sorted {
+		urls[i] = image.URL
+	}
+	return urls
+}
+
+// RunListingImageBackfill periodically fills in the metadata and variants of
+// images uploaded before they were recorded
+func RunListingImageBackfill(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		backfillListingImages(context.Background())
+		<-ticker.C
+	}
+}
+
+func backfillListingImages(ctx context.Context) {
+	var images []models.ListingImage
+	if err := database.DB.
+		Where("content_hash IS NULL OR card_url IS NULL").
+		Order("id").
+		Limit(listingImageBackfillBatch).
+		Find(&images).Error; err != nil {
+		log.Printf("warning: failed to fetch listing images to backfill: %v", err)
+		return
+	}
+
+	for _, image := range images {
+		updates := map[string]interface{}{}
+
+		data, _, err := GetImageByURL(ctx, image.URL)
+		if isMissingObject(err) {
+			// empty values mark the image as gone so it isn't retried forever,
+			// images that can't be decoded are marked the same way below
+			log.Printf("warning: listing image %d is missing from storage", image.ID)
+			updates["content_hash"] = ""
+			updates["card_url"] = ""
+			updates["thumb_url"] = ""
+		} else if err != nil {
+			// storage errors are worth another try on the next run
+			log.Printf("warning: failed to backfill listing image %d: %v", image.ID, err)
+			continue
+		} else {
+			if image.ContentHash == nil {
+				for k, v := range listingImageMetadata(image, data) {
+					updates[k] = v
+				}
+			}
+			if image.CardURL == nil {
+				for k, v := range listingImageVariants(ctx, image, data) {
+					updates[k] = v
+				}
+			}
+		}
+
+		if len(updates) == 0 {
+			continue
+		}
+
+		if err := database.DB.Model(&image).UpdateColumns(updates).Error; err != nil {
+			log.Printf("warning: failed to save listing image %d metadata: %v", image.ID, err)
+		}
+	}
+}
+
+func listingImageMetadata(image models.ListingImage, data []byte) map[string]interface{} {
+	stored, err := inspectImage(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("warning: failed to inspect listing image %d: %v", image.ID, err)
+		return map[string]interface{}{"content_hash": ""}
+	}
+
+	updates := map[string]interface{}{
+		"byte_size":    stored.ByteSize,
+		"content_hash": stored.ContentHash,
+	}
+	if stored.Width > 0 && stored.Height > 0 {
+		updates["width"] = stored.Width
+		updates["height"] = stored.Height
+	}
+	return updates
+}
+
+// listingImageVariants makes the smaller variants of an older image. The image
+// itself stays as it is, its URL is already out there.
+func listingImageVariants(ctx context.Context, image models.ListingImage, data []byte) map[string]interface{} {
+	failed := map[string]interface{}{"card_url": "", "thumb_url": ""}
+
+	key, err := imageKey(image.URL)
+	if err != nil {
+		log.Printf("warning: failed to make variants of listing image %d: %v", image.ID, err)
+		return failed
+	}
+
+	processed, err := processImage(data)
+	if err != nil {
+		log.Printf("warning: failed to make variants of listing image %d: %v", image.ID, err)
+		return failed
+	}
+
+	var variants []processedImage
+	for _, p := range processed {
+		if p.variant != ImageVariantFull {
+			variants = append(variants, p)
+		}
+	}
+
+	stored, err := storeImageVariants(ctx, key, variants)
+	if err != nil {
+		// storage errors are worth another try on the next run
+		log.Printf("warning: failed to store variants of listing image %d: %v", image.ID, err)
+		return nil
+	}
+
+	return map[string]interface{}{
+		"card_url":  stored.CardURL,
+		"thumb_url": stored.ThumbURL,
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"time"
+)
+
+// imports that made no progress for this long were interrupted by a restart,
+// every row updates the import so a running one never gets this old
+const listingImportStaleAfter = time.Hour
+
+// RunListingImportCleanup periodically fails imports that were interrupted
+func RunListingImportCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		failStaleListingImports()
+		<-ticker.C
+	}
+}
+
+func failStaleListingImports() {
+	if err := database.DB.Model(&models.ListingImport{}).
+		Where("status IN ? AND updated_at < ?",
+			[]models.ListingImportStatus{models.ListingImportPending, models.ListingImportProcessing},
+			time.Now().Add(-listingImportStaleAfter)).
+		Updates(map[string]interface{}{
+			"status": models.ListingImportFailed,
+			"error":  "import was interrupted, rows without a result were not imported",
+		}).Error; err != nil {
+		log.Printf("warning: failed to clean up listing imports: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordListingRevision stores the listing's editable fields as its next revision.
+// before is the state prior to the edit, nil for a new listing. Nothing is recorded
+// when the edit changed none of the tracked fields. Run it in the caller's transaction.
+func RecordListingRevision(tx *gorm.DB, before *models.ListingSnapshot, listing models.Listing, actor models.ListingActor, actorID *uint) error {
+	snapshot := models.SnapshotListing(listing)
+
+	changes := models.ListingChanges{}
+	if before != nil {
+		changes = models.DiffListingSnapshots(*before, snapshot)
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+
+	var latest int
+	if err := tx.Model(&models.ListingRevision{}).
+		Where("listing_id = ?", listing.ID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&latest).Error; err != nil {
+		return fmt.Errorf("failed to find latest revision: %w", err)
+	}
+
+	revision := models.ListingRevision{
+		ListingID: listing.ID,
+		Revision:  latest + 1,
+		Actor:     actor,
+		ActorID:   actorID,
+		Changes:   changes,
+		Snapshot:  snapshot,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return fmt.Errorf("failed to record listing revision: %w", err)
+	}
+
+	return nil
+}
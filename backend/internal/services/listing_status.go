@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// listingStatusTimestamps maps a status to the column recording when it was last entered
+var listingStatusTimestamps = map[models.ListingStatus]string{
+	models.ListingActive:   "published_at",
+	models.ListingReserved: "reserved_at",
+	models.ListingSold:     "sold_at",
+	models.ListingExpired:  "expired_at",
+	models.ListingArchived: "archived_at",
+	models.ListingRemoved:  "removed_at",
+}
+
+// TransitionListing moves the listing to a new status, stamps the matching
+// timestamp and records the change in the transition history. Run it inside
+// the caller's transaction so the status and its history are saved together.
+func TransitionListing(tx *gorm.DB, listing *models.Listing, to models.ListingStatus, actor models.ListingActor, actorID *uint, reason string) error {
+	from := listing.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("cannot change listing status from %s to %s", from, to)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	if column, ok := listingStatusTimestamps[to]; ok {
+		updates[column] = now
+	}
+
+	// every publish starts a fresh expiry period
+	if to == models.ListingActive {
+		updates["expires_at"] = now.Add(ListingExpiryPeriod(listing.Category))
+		updates["expiry_reminded_at"] = nil
+	}
+
+	if err := tx.Model(listing).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update listing status: %w", err)
+	}
+	setListingStatus(listing, to, now)
+
+	transition := models.ListingStatusTransition{
+		ListingID:  listing.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	if err := tx.Create(&transition).Error; err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+
+	return nil
+}
+
+func setListingStatus(listing *models.Listing, status models.ListingStatus, at time.Time) {
+	listing.Status = status
+
+	switch status {
+	case models.ListingActive:
+		expiresAt := at.Add(ListingExpiryPeriod(listing.Category))
+		listing.PublishedAt = &at
+		listing.ExpiresAt = &expiresAt
+		listing.ExpiryRemindedAt = nil
+	case models.ListingReserved:
+		listing.ReservedAt = &at
+	case models.ListingSold:
+		listing.SoldAt = &at
+	case models.ListingExpired:
+		listing.ExpiredAt = &at
+	case models.ListingArchived:
+		listing.ArchivedAt = &at
+	case models.ListingRemoved:
+		listing.RemovedAt = &at
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const defaultListingTrashDays = 30
+
+// ListingTrashRetention is how long a deleted listing can be restored before its images are purged
+func ListingTrashRetention() time.Duration {
+	days := defaultListingTrashDays
+	if value := os.Getenv("LISTING_TRASH_DAYS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			days = n
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RunListingPurge periodically deletes the images of listings that stayed in
+// the trash past the retention period. The rows are kept so ratings still
+// point at the listing they were given for.
+func RunListingPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeDeletedListings()
+		<-ticker.C
+	}
+}
+
+func purgeDeletedListings() {
+	var listings []models.Listing
+	if err := database.DB.Unscoped().
+		Where("deleted_at < ? AND images_purged_at IS NULL", time.Now().Add(-ListingTrashRetention())).
+		Find(&listings).Error; err != nil {
+		log.Printf("warning: failed to fetch deleted listings: %v", err)
+		return
+	}
+
+	for _, listing := range listings {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&listing).Updates(map[string]interface{}{
+				"image_urls":       pq.StringArray{},
+				"images_purged_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("listing_id = ?", listing.ID).Delete(&models.ListingImage{}).Error; err != nil {
+				return err
+			}
+			return QueueImageDeletes(tx, listing.ImageURLs)
+		})
+		if err != nil {
+			log.Printf("warning: failed to purge listing %d: %v", listing.ID, err)
+		}
+	}
+
+	WakeStorageOutbox()
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"os"
+	"strings"
+)
+
+// Notify stores an in-app notification and, when email is set, mails it to the user.
+// Email failures are only logged, the in-app notification is what counts.
+func Notify(notification models.Notification, email bool) error {
+	if err := database.DB.Create(&notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	if !email {
+		return nil
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "email").First(&user, notification.UserID).Error; err != nil {
+		log.Printf("warning: notification %d not emailed: %v", notification.ID, err)
+		return nil
+	}
+
+	body := notification.Body
+	if notification.ListingID != nil {
+		body += "\n\n" + ListingURL(*notification.ListingID)
+	}
+
+	if err := SendEmail(user.Email, notification.Title, body); err != nil {
+		log.Printf("warning: notification %d not emailed: %v", notification.ID, err)
+	}
+
+	return nil
+}
+
+// ListingURL links to a listing page in the frontend
+func ListingURL(listingID uint) string {
+	return fmt.Sprintf("%s/listings/%d", frontendURL(), listingID)
+}
+
+func frontendURL() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:5173"
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"math"
+	"os"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const defaultPriceDropAlertPercent = 5
+
+// PriceDropAlertPercent is how much a price has to fall, in percent, before the
+// users who wishlisted the listing are alerted. PRICE_DROP_ALERT_PERCENT overrides it.
+func PriceDropAlertPercent() float64 {
+	if value := os.Getenv("PRICE_DROP_ALERT_PERCENT"); value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 && n < 100 {
+			return n
+		}
+	}
+	return defaultPriceDropAlertPercent
+}
+
+// ListingPriceOf returns the listing's current price as a history entry
+func ListingPriceOf(listing models.Listing) models.ListingPrice {
+	return models.ListingPrice{
+		ListingID:  listing.ID,
+		PriceMinor: listing.PriceMinor,
+		Currency:   listing.Currency,
+		Price:      listing.Price,
+		PriceBase:  listing.PriceBase,
+	}
+}
+
+// RecordListingPrice adds the listing's price to its history unless it equals the
+// latest entry. Run it in the caller's transaction.
+func RecordListingPrice(tx *gorm.DB, listing models.Listing) error {
+	var latest models.ListingPrice
+	err := tx.Where("listing_id = ?", listing.ID).Order("created_at DESC, id DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return fmt.Errorf("failed to find latest price: %w", err)
+	}
+
+	if latest.ID != 0 && latest.PriceMinor == listing.PriceMinor && latest.Currency == listing.Currency {
+		return nil
+	}
+
+	entry := ListingPriceOf(listing)
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record listing price: %w", err)
+	}
+
+	return nil
+}
+
+// NotifyPriceDrop alerts everyone who wishlisted the listing when its price fell by
+// at least PriceDropAlertPercent since previous. Prices are compared in the base
+// currency so a change of currency alone doesn't count. Meant to run in the background.
+func NotifyPriceDrop(listing models.Listing, previous models.ListingPrice) {
+	if !listing.Status.IsVisible() || previous.PriceBase <= 0 {
+		return
+	}
+
+	drop := (previous.PriceBase - listing.PriceBase) / previous.PriceBase * 100
+	if drop < PriceDropAlertPercent() {
+		return
+	}
+
+	var userIDs []uint
+	if err := database.DB.Model(&models.WishlistListing{}).
+		Where("listing_id = ? AND user_id <> ?", listing.ID, listing.UserID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("warning: failed to fetch wishlisters of listing %d: %v", listing.ID, err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		listingID := listing.ID
+		notification := models.Notification{
+			UserID: userID,
+			Type:   models.NotificationPriceDrop,
+			Title:  fmt.Sprintf("Price drop on \"%s\"", listing.Title),
+			Body: fmt.Sprintf("Now %.2f %s, was %.2f %s (-%d%%).",
+				listing.Price, listing.Currency, previous.Price, previous.Currency, int(math.Round(drop))),
+			ListingID: &listingID,
+		}
+		if err := Notify(notification, true); err != nil {
+			log.Printf("warning: %v", err)
+		}
+	}
+}
@@ -1,83 +1,182 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"gin-backend/internal/database"
 	"gin-backend/internal/models"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
 	"github.com/google/uuid"
 )
 
+// StoredImage is an uploaded image and what was learned about it on the way in.
+// Width and Height are 0 when the format can't be decoded.
+type StoredImage struct {
+	URL         string
+	CardURL     string
+	ThumbURL    string
+	Width       int
+	Height      int
+	ByteSize    int64
+	ContentHash string
+}
+
 func UploadImage(ctx context.Context, file *multipart.FileHeader, user *models.User, folder string) (string, error) {
-	accountHash := os.Getenv("R2_ACCOUNT_HASH")
-	bucketName := os.Getenv("R2_BUCKET_NAME")
+	stored, err := UploadImageFile(ctx, file, user, folder)
+	return stored.URL, err
+}
 
+// UploadImageFile is UploadImage for callers that keep the image's metadata
+func UploadImageFile(ctx context.Context, file *multipart.FileHeader, user *models.User, folder string) (StoredImage, error) {
 	src, err := file.Open()
 	if err != nil {
-		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+		return StoredImage{}, fmt.Errorf("failed to open uploaded file: %w", err)
 	}
 
 	defer src.Close()
 
+	return UploadImageReader(ctx, src, user, folder)
+}
+
+// UploadImageReader uploads an image that didn't come from a form, like one
+// extracted from an archive. The image is stored as its variants, never as sent.
+func UploadImageReader(ctx context.Context, src io.ReadSeeker, user *models.User, folder string) (StoredImage, error) {
 	buf := make([]byte, 512)
 	n, err := src.Read(buf)
 	if err != nil && err != io.EOF {
-		return "", fmt.Errorf("failed to read file for type detection: %w", err)
+		return StoredImage{}, fmt.Errorf("failed to read file for type detection: %w", err)
 	}
 
 	contentType := http.DetectContentType(buf[:n])
 
 	if !strings.HasPrefix(contentType, "image/") {
-		return "", fmt.Errorf("uploaded file is not an image: %w", err)
+		return StoredImage{}, fmt.Errorf("uploaded file is not an image: %w", err)
 	}
 
-	_, err = src.Seek(0, io.SeekStart)
+	data, err := readAllSeeker(src)
 	if err != nil {
-		return "", fmt.Errorf("failed to reset file stream: %w", err)
+		return StoredImage{}, err
 	}
 
-	fileExtension := filepath.Ext(file.Filename)
-	key := fmt.Sprintf("%s/%d/%s%s", folder, user.ID, uuid.NewString(), fileExtension)
+	processed, err := processImage(data)
+	if err != nil {
+		return StoredImage{}, err
+	}
 
-	_, err = database.S3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(bucketName),
-		Key:         aws.String(key),
-		Body:        src,
-		ContentType: aws.String(contentType),
-	})
+	key := fmt.Sprintf("%s/%d/%s.jpg", folder, user.ID, uuid.NewString())
+
+	return storeImageVariants(ctx, key, processed)
+}
+
+// storeImageVariants uploads processed variants of the image stored under key,
+// removing the ones already uploaded if one fails
+func storeImageVariants(ctx context.Context, key string, processed []processedImage) (StoredImage, error) {
+	var stored StoredImage
+	var uploaded []string
+
+	for _, p := range processed {
+		variantKey := imageVariantKey(key, p.variant)
+		if err := PutObject(ctx, variantKey, bytes.NewReader(p.data), "image/jpeg"); err != nil {
+			for _, k := range uploaded {
+				if err := DeleteObject(ctx, k); err != nil {
+					log.Printf("warning: %v", err)
+				}
+			}
+			return StoredImage{}, fmt.Errorf("failed to upload image to storage: %w", err)
+		}
+		uploaded = append(uploaded, variantKey)
+
+		switch p.variant {
+		case ImageVariantFull:
+			hash := sha256.Sum256(p.data)
+			stored.URL = publicImageURL(variantKey)
+			stored.Width = p.width
+			stored.Height = p.height
+			stored.ByteSize = int64(len(p.data))
+			stored.ContentHash = hex.EncodeToString(hash[:])
+		case ImageVariantCard:
+			stored.CardURL = publicImageURL(variantKey)
+		case ImageVariantThumb:
+			stored.ThumbURL = publicImageURL(variantKey)
+		}
+	}
+
+	return stored, nil
+}
+
+// inspectImage hashes the image and reads its dimensions, leaving src at the start
+func inspectImage(src io.ReadSeeker) (StoredImage, error) {
+	var stored StoredImage
 
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return stored, fmt.Errorf("failed to reset file stream: %w", err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, src)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload image to storage: %w", err)
+		return stored, fmt.Errorf("failed to read image: %w", err)
 	}
+	stored.ByteSize = size
+	stored.ContentHash = hex.EncodeToString(hash.Sum(nil))
 
-	publicURL := fmt.Sprintf("https://pub-%s.r2.dev", accountHash)
-	imageURL := fmt.Sprintf("%s/%s", publicURL, key)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return stored, fmt.Errorf("failed to reset file stream: %w", err)
+	}
 
-	return imageURL, nil
+	// formats without a registered decoder just have no dimensions
+	if config, _, err := image.DecodeConfig(src); err == nil {
+		stored.Width = config.Width
+		stored.Height = config.Height
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return stored, fmt.Errorf("failed to reset file stream: %w", err)
+	}
+
+	return stored, nil
 }
 
-func UploadImages(ctx context.Context, files []*multipart.FileHeader, user *models.User, folder string) ([]string, error) {
-	var urls []string
+// publicImageURL is the address images are served from
+func publicImageURL(key string) string {
+	accountHash := os.Getenv("R2_ACCOUNT_HASH")
+
+	publicURL := fmt.Sprintf("https://pub-%s.r2.dev", accountHash)
+	return fmt.Sprintf("%s/%s", publicURL, key)
+}
+
+func UploadImages(ctx context.Context, files []*multipart.FileHeader, user *models.User, folder string) ([]StoredImage, error) {
+	var images []StoredImage
 
 	for _, file := range files {
-		url, err := UploadImage(ctx, file, user, folder)
+		stored, err := UploadImageFile(ctx, file, user, folder)
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload %s: %w", file.Filename, err)
 		}
-		urls = append(urls, url)
+		images = append(images, stored)
 	}
 
-	return urls, nil
+	return images, nil
 }
 
 func DeleteImageByURL(ctx context.Context, imageURL string) error {
@@ -85,29 +184,220 @@ func DeleteImageByURL(ctx context.Context, imageURL string) error {
 		return nil
 	}
 
+	key, err := imageKey(imageURL)
+	if err != nil {
+		return err
+	}
+
+	// images uploaded before variants existed just have nothing to delete there
+	for _, variant := range imageVariants {
+		if err := DeleteObject(ctx, imageVariantKey(key, variant)); err != nil {
+			return fmt.Errorf("failed to delete image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteImages removes images that are no longer referenced, failures are only logged
+func DeleteImages(ctx context.Context, imageURLs []string) {
+	for _, imageURL := range imageURLs {
+		if err := DeleteImageByURL(ctx, imageURL); err != nil {
+			log.Printf("warning: failed to delete image %s: %v", imageURL, err)
+		}
+	}
+}
+
+// CopyImage stores a copy of an image and its variants under a new key in the
+// user's folder, so the copy stays when the original is deleted
+func CopyImage(ctx context.Context, imageURL string, user *models.User, folder string) (string, error) {
+	key, err := imageKey(imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	newKey := fmt.Sprintf("%s/%d/%s%s", folder, user.ID, uuid.NewString(), path.Ext(key))
+
+	for _, variant := range imageVariants {
+		err := copyObject(ctx, imageVariantKey(key, variant), imageVariantKey(newKey, variant))
+
+		// older images only have the full variant
+		if variant != ImageVariantFull && isMissingObject(err) {
+			continue
+		}
+		if err != nil {
+			DeleteImages(ctx, []string{publicImageURL(newKey)})
+			return "", fmt.Errorf("failed to copy image %s: %w", key, err)
+		}
+	}
+
+	return publicImageURL(newKey), nil
+}
+
+func copyObject(ctx context.Context, from string, to string) error {
 	bucketName := os.Getenv("R2_BUCKET_NAME")
 
+	_, err := database.S3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(bucketName),
+		Key:        aws.String(to),
+		CopySource: aws.String(bucketName + "/" + (&url.URL{Path: from}).EscapedPath()),
+	})
+	return err
+}
+
+// CopyImages copies every image, removing the copies already made if one fails
+func CopyImages(ctx context.Context, imageURLs []string, user *models.User, folder string) ([]string, error) {
+	var urls []string
+
+	for _, imageURL := range imageURLs {
+		copied, err := CopyImage(ctx, imageURL, user, folder)
+		if err != nil {
+			DeleteImages(ctx, urls)
+			return nil, err
+		}
+		urls = append(urls, copied)
+	}
+
+	return urls, nil
+}
+
+// imageKey returns the storage key of a public image URL
+func imageKey(imageURL string) (string, error) {
 	u, err := url.Parse(imageURL)
 	if err != nil {
-		return fmt.Errorf("invalid image URL: %w", err)
+		return "", fmt.Errorf("invalid image URL: %w", err)
 	}
 
 	key := strings.TrimPrefix(u.Path, "/")
 	if key == "" {
-		return fmt.Errorf("invalid image key")
+		return "", fmt.Errorf("invalid image key")
 	}
 
-	_, err = database.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+	return key, nil
+}
+
+// isMissingObject reports whether err means the object isn't in the bucket
+func isMissingObject(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey"
+}
+
+// DeleteObject removes a single object from the bucket by its key
+func DeleteObject(ctx context.Context, key string) error {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	_, err := database.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete image: %w", err)
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// ListObjects calls fn for every object whose key starts with prefix
+func ListObjects(ctx context.Context, prefix string, fn func(key string, size int64, modified time.Time)) error {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	paginator := s3.NewListObjectsV2Paginator(database.S3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+		for _, object := range page.Contents {
+			fn(aws.ToString(object.Key), aws.ToInt64(object.Size), aws.ToTime(object.LastModified))
+		}
 	}
 
 	return nil
 }
 
+// PutObject stores a private object under the given key
+func PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	_, err := database.S3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// PresignDownloadURL returns a time-limited GET link for a private object.
+// The browser saves the object under filename.
+func PresignDownloadURL(ctx context.Context, key string, filename string, ttl time.Duration) (string, error) {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	presigner := s3.NewPresignClient(database.S3Client)
+	req, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(bucketName),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=%q", filename)),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+
+	return req.URL, nil
+}
+
+var errObjectTooLarge = errors.New("object is too large")
+
+// PresignUploadURL returns a time-limited PUT link for key. The type and size
+// are part of the signature, the returned headers must be sent as they are.
+func PresignUploadURL(ctx context.Context, key string, contentType string, size int64, ttl time.Duration) (string, http.Header, error) {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	presigner := s3.NewPresignClient(database.S3Client)
+	req, err := presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	return req.URL, req.SignedHeader, nil
+}
+
+// GetObject reads an object, refusing ones larger than maxBytes
+func GetObject(ctx context.Context, key string, maxBytes int64) ([]byte, error) {
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+
+	result, err := database.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve object %s: %w", key, err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(result.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", errObjectTooLarge, key, maxBytes)
+	}
+
+	return data, nil
+}
+
 func GetImageByURL(ctx context.Context, imageURL string) ([]byte, string, error) {
 	if imageURL == "" {
 		return nil, "", fmt.Errorf("image URL cannot be empty")
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	savedSearchDigestPeriod = 24 * time.Hour
+	// listings spelled out in a digest, the rest are summarised by count
+	savedSearchDigestMaxListed = 10
+)
+
+// RunSavedSearchDigest periodically sends one summary per daily saved search
+// that collected new matches since its last digest
+func RunSavedSearchDigest(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sendSavedSearchDigests()
+		<-ticker.C
+	}
+}
+
+func sendSavedSearchDigests() {
+	var searches []models.SavedSearch
+	if err := database.DB.
+		Where("frequency = ?", models.SavedSearchDaily).
+		Where("last_digest_at IS NULL OR last_digest_at <= ?", time.Now().Add(-savedSearchDigestPeriod)).
+		Where("EXISTS (SELECT 1 FROM saved_search_matches WHERE saved_search_matches.saved_search_id = saved_searches.id AND saved_search_matches.notified_at IS NULL)").
+		Find(&searches).Error; err != nil {
+		log.Printf("warning: failed to fetch saved searches for digest: %v", err)
+		return
+	}
+
+	for _, search := range searches {
+		if err := sendSavedSearchDigest(search); err != nil {
+			log.Printf("warning: saved search %d digest failed: %v", search.ID, err)
+		}
+	}
+}
+
+func sendSavedSearchDigest(search models.SavedSearch) error {
+	var matches []models.SavedSearchMatch
+	if err := database.DB.
+		Preload("Listing").
+		Where("saved_search_id = ? AND notified_at IS NULL", search.ID).
+		Order("created_at DESC").
+		Find(&matches).Error; err != nil {
+		return err
+	}
+
+	// listings sold, archived or removed since they matched are dropped from the digest
+	matchIDs := make([]uint, len(matches))
+	var open []models.SavedSearchMatch
+	for i, match := range matches {
+		matchIDs[i] = match.ID
+		if match.Listing != nil && match.Listing.Status.IsVisible() {
+			open = append(open, match)
+		}
+	}
+
+	if len(open) > 0 {
+		var lines []string
+		for i, match := range open {
+			if i == savedSearchDigestMaxListed {
+				lines = append(lines, fmt.Sprintf("...and %d more", len(open)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("- %s (%.2f %s) %s", match.Listing.Title, match.Listing.Price, match.Listing.Currency, ListingURL(match.ListingID)))
+		}
+
+		notification := models.Notification{
+			UserID: search.UserID,
+			Type:   models.NotificationSavedSearchDigest,
+			Title:  fmt.Sprintf("%d new listings for \"%s\"", len(open), search.Name),
+			Body:   strings.Join(lines, "\n"),
+		}
+		if err := Notify(notification, true); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if len(matchIDs) > 0 {
+		database.DB.Model(&models.SavedSearchMatch{}).
+			Where("id IN ?", matchIDs).
+			Update("notified_at", now)
+	}
+
+	return database.DB.Model(&search).Update("last_digest_at", now).Error
+}
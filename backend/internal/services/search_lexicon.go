@@ -0,0 +1,20 @@
+package services
+
+import (
+	"gin-backend/internal/database"
+	"log"
+	"time"
+)
+
+// RunSearchLexiconRefresh periodically rebuilds the word list behind
+// "did you mean" suggestions so new listing titles are picked up
+func RunSearchLexiconRefresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := database.DB.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY listing_title_words").Error; err != nil {
+			log.Printf("warning: failed to refresh search lexicon: %v", err)
+		}
+	}
+}
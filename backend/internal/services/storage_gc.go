@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStorageGCGraceHours = 72
+	// orphans deleted in one run at most, whatever is left goes in the next one
+	storageGCMaxDeletes = 1000
+	// orphan keys listed in a run's report
+	storageGCReportKeys = 500
+)
+
+// storageGCPrefixes are the folders holding images something in the database
+// points at. Uploads and exports clean up after themselves.
+var storageGCPrefixes = []string{"listings/", "avatars/", "templates/"}
+
+// ErrStorageGCRunning is returned when a run is asked for while one is going
+var ErrStorageGCRunning = errors.New("storage cleanup is already running")
+
+var storageGCMu sync.Mutex
+
+// StorageGCGracePeriod is how old an unreferenced object has to be before it's
+// collected. Younger ones may belong to a listing that's still being saved.
+func StorageGCGracePeriod() time.Duration {
+	hours := defaultStorageGCGraceHours
+	if value := os.Getenv("STORAGE_GC_GRACE_HOURS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			hours = n
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// StorageGCDryRun reports whether scheduled runs only report orphans, which
+// they do unless STORAGE_GC_DRY_RUN is set to false
+func StorageGCDryRun() bool {
+	return os.Getenv("STORAGE_GC_DRY_RUN") != "false"
+}
+
+// RunStorageGC periodically removes images no listing, template, user or upload
+// references anymore
+func RunStorageGC(interval time.Duration) {
+	// nothing is running yet, so runs still marked running were cut off by a restart
+	if err := database.DB.Model(&models.StorageGCRun{}).
+		Where("status = ?", models.StorageGCRunning).
+		Updates(map[string]interface{}{
+			"status": models.StorageGCFailed,
+			"error":  "run was interrupted",
+		}).Error; err != nil {
+		log.Printf("warning: failed to clean up storage cleanup runs: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := StartStorageGC(StorageGCDryRun())
+		if err != nil {
+			log.Printf("warning: storage cleanup not started: %v", err)
+		} else {
+			log.Printf("storage cleanup run %d started", run.ID)
+		}
+		<-ticker.C
+	}
+}
+
+// StartStorageGC records a new run and starts it in the background
+func StartStorageGC(dryRun bool) (models.StorageGCRun, error) {
+	if !storageGCMu.TryLock() {
+		return models.StorageGCRun{}, ErrStorageGCRunning
+	}
+
+	run := models.StorageGCRun{
+		Status:           models.StorageGCRunning,
+		DryRun:           dryRun,
+		GracePeriodHours: int(StorageGCGracePeriod() / time.Hour),
+	}
+	if err := database.DB.Create(&run).Error; err != nil {
+		storageGCMu.Unlock()
+		return run, fmt.Errorf("failed to record storage cleanup run: %w", err)
+	}
+
+	go func() {
+		defer storageGCMu.Unlock()
+		finishStorageGC(run, collectStorageGarbage(context.Background(), &run))
+	}()
+
+	return run, nil
+}
+
+func finishStorageGC(run models.StorageGCRun, err error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.StorageGCCompleted
+	if err != nil {
+		log.Printf("warning: storage cleanup run %d failed: %v", run.ID, err)
+		run.Status = models.StorageGCFailed
+		run.Error = err.Error()
+	}
+
+	if err := database.DB.Save(&run).Error; err != nil {
+		log.Printf("warning: failed to save storage cleanup run %d: %v", run.ID, err)
+	}
+}
+
+type storageObject struct {
+	key  string
+	size int64
+}
+
+func collectStorageGarbage(ctx context.Context, run *models.StorageGCRun) error {
+	cutoff := time.Now().Add(-time.Duration(run.GracePeriodHours) * time.Hour)
+
+	// objects are listed before references are loaded, so an object that gets
+	// referenced in between is seen as referenced
+	var candidates []storageObject
+	for _, prefix := range storageGCPrefixes {
+		err := ListObjects(ctx, prefix, func(key string, size int64, modified time.Time) {
+			run.ScannedObjects++
+			run.ScannedBytes += size
+			if modified.After(cutoff) {
+				run.RecentObjects++
+				return
+			}
+			candidates = append(candidates, storageObject{key: key, size: size})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	referenced, err := storageReferences()
+	if err != nil {
+		return err
+	}
+	// an empty result is far more likely a broken query than an empty site
+	if len(referenced) == 0 && len(candidates) > 0 {
+		return errors.New("no references found, refusing to treat every object as orphaned")
+	}
+
+	var orphans []storageObject
+	for _, object := range candidates {
+		if referenced[object.key] {
+			continue
+		}
+		orphans = append(orphans, object)
+		run.OrphanedObjects++
+		run.OrphanedBytes += object.size
+		if len(run.OrphanKeys) < storageGCReportKeys {
+			run.OrphanKeys = append(run.OrphanKeys, object.key)
+		}
+	}
+
+	if run.DryRun {
+		return nil
+	}
+
+	for _, object := range orphans[:min(len(orphans), storageGCMaxDeletes)] {
+		if err := DeleteObject(ctx, object.key); err != nil {
+			log.Printf("warning: %v", err)
+			run.FailedDeletes++
+			continue
+		}
+		run.DeletedObjects++
+		run.DeletedBytes += object.size
+	}
+
+	return nil
+}
+
+// storageReferences returns the keys of every image still in use, with their
+// variants. Trashed listings count until their images are purged.
+func storageReferences() (map[string]bool, error) {
+	queries := []string{
+		"SELECT url FROM listing_images",
+		"SELECT DISTINCT unnest(image_urls) FROM listings",
+		"SELECT DISTINCT unnest(image_urls) FROM listing_templates",
+		"SELECT avatar_url FROM users WHERE avatar_url <> ''",
+		"SELECT image_url FROM uploads WHERE image_url IS NOT NULL",
+	}
+
+	referenced := make(map[string]bool)
+	for _, query := range queries {
+		var urls []string
+		if err := database.DB.Raw(query).Scan(&urls).Error; err != nil {
+			return nil, fmt.Errorf("failed to load image references: %w", err)
+		}
+
+		for _, url := range urls {
+			key, err := imageKey(url)
+			if err != nil {
+				continue
+			}
+			for _, variant := range imageVariants {
+				referenced[imageVariantKey(key, variant)] = true
+			}
+		}
+	}
+
+	return referenced, nil
+}
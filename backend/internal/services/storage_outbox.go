@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// entries carried out per batch, the worker keeps going while batches are full
+	storageOutboxBatch = 50
+	// failed attempts before an entry is dead-lettered
+	storageOutboxMaxAttempts = 12
+	storageOutboxBaseBackoff = 30 * time.Second
+	storageOutboxMaxBackoff  = 6 * time.Hour
+	// claimed entries aren't due again for this long, entries of a worker that
+	// died come back once it's over
+	storageOutboxLease = 5 * time.Minute
+)
+
+// storageOutboxWake lets a committed transaction hurry the worker along
+var storageOutboxWake = make(chan struct{}, 1)
+
+// QueueImageDeletes records images to delete once tx commits. A rollback drops
+// them along with everything else, so data and storage never disagree.
+func QueueImageDeletes(tx *gorm.DB, imageURLs []string) error {
+	return queueStorageOperations(tx, models.StorageDeleteImage, imageURLs)
+}
+
+// QueueObjectDeletes is QueueImageDeletes for private objects given by key
+func QueueObjectDeletes(tx *gorm.DB, keys []string) error {
+	return queueStorageOperations(tx, models.StorageDeleteObject, keys)
+}
+
+func queueStorageOperations(tx *gorm.DB, operation models.StorageOperation, targets []string) error {
+	var entries []models.StorageOutboxEntry
+	for _, target := range targets {
+		if target == "" {
+			continue
+		}
+		entries = append(entries, models.StorageOutboxEntry{
+			Operation:     operation,
+			Target:        target,
+			Status:        models.StorageOutboxPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&entries).Error; err != nil {
+		return fmt.Errorf("failed to queue storage deletes: %w", err)
+	}
+	return nil
+}
+
+// WakeStorageOutbox tells the worker there's new work, call it after the commit
+func WakeStorageOutbox() {
+	select {
+	case storageOutboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunStorageOutbox carries out queued storage operations, retrying failures
+// with a growing delay until they're dead-lettered
+func RunStorageOutbox(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means more is waiting
+		for processStorageOutbox(context.Background()) == storageOutboxBatch {
+		}
+
+		select {
+		case <-ticker.C:
+		case <-storageOutboxWake:
+		}
+	}
+}
+
+// processStorageOutbox carries out one batch of due entries and returns how many
+// it took. Storage is called outside of any transaction, every entry is removed
+// or rescheduled on its own once its operation is done.
+func processStorageOutbox(ctx context.Context) int {
+	entries, err := claimStorageOutboxEntries()
+	if err != nil {
+		log.Printf("warning: failed to process storage outbox: %v", err)
+		return 0
+	}
+
+	for _, entry := range entries {
+		if err := runStorageOperation(ctx, entry); err != nil {
+			if err := failStorageOutboxEntry(database.DB, entry, err); err != nil {
+				log.Printf("warning: failed to reschedule storage outbox entry %d: %v", entry.ID, err)
+			}
+			continue
+		}
+
+		// the operations are idempotent, an entry left over runs again after its lease
+		if err := database.DB.Delete(&entry).Error; err != nil {
+			log.Printf("warning: failed to remove storage outbox entry %d: %v", entry.ID, err)
+		}
+	}
+
+	return len(entries)
+}
+
+// claimStorageOutboxEntries leases a batch of due entries by moving their next
+// attempt past storageOutboxLease, so several instances can share the table
+func claimStorageOutboxEntries() ([]models.StorageOutboxEntry, error) {
+	var entries []models.StorageOutboxEntry
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.StorageOutboxPending, time.Now()).
+			Order("id").
+			Limit(storageOutboxBatch).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ID
+		}
+
+		return tx.Model(&models.StorageOutboxEntry{}).
+			Where("id IN ?", ids).
+			UpdateColumn("next_attempt_at", time.Now().Add(storageOutboxLease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func runStorageOperation(ctx context.Context, entry models.StorageOutboxEntry) error {
+	switch entry.Operation {
+	case models.StorageDeleteImage:
+		return DeleteImageByURL(ctx, entry.Target)
+	case models.StorageDeleteObject:
+		return DeleteObject(ctx, entry.Target)
+	default:
+		return fmt.Errorf("unknown storage operation %q", entry.Operation)
+	}
+}
+
+func failStorageOutboxEntry(tx *gorm.DB, entry models.StorageOutboxEntry, cause error) error {
+	attempts := entry.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": cause.Error(),
+	}
+
+	if attempts >= storageOutboxMaxAttempts {
+		log.Printf("warning: storage outbox entry %d dead-lettered after %d attempts: %v", entry.ID, attempts, cause)
+		updates["status"] = models.StorageOutboxDead
+		updates["dead_at"] = time.Now()
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(storageOutboxBackoff(attempts))
+	}
+
+	return tx.Model(&entry).Updates(updates).Error
+}
+
+// storageOutboxBackoff doubles the delay with every failed attempt
+func storageOutboxBackoff(attempts int) time.Duration {
+	backoff := storageOutboxBaseBackoff
+	for i := 1; i < attempts && backoff < storageOutboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, storageOutboxMaxBackoff)
+}
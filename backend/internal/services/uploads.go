@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gin-backend/internal/database"
+	"gin-backend/internal/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxUploadSize is the largest file a presigned upload accepts
+	MaxUploadSize = 10 << 20
+	// UploadURLTTL is how long a presigned upload URL works
+	UploadURLTTL = 15 * time.Minute
+	// uploads never confirmed are removed after this
+	uploadPendingTTL = time.Hour
+	// confirmed uploads never attached to anything are removed after this
+	uploadConfirmedTTL = 24 * time.Hour
+	// uploads removed on each cleanup run
+	uploadCleanupBatch = 100
+)
+
+// UploadContentTypes are the types a presigned upload can be announced with
+var UploadContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// uploadFolders is where confirmed images are stored for each purpose
+var uploadFolders = map[models.UploadPurpose]string{
+	models.UploadPurposeListing: "listings",
+	models.UploadPurposeAvatar:  "avatars",
+}
+
+// ErrUploadRejected is returned for uploads that don't match what was announced
+// or can't be used, the message is meant for the client
+var ErrUploadRejected = errors.New("upload rejected")
+
+// NewUpload announces a direct upload and returns it, not saved yet, with the
+// URL the file is PUT to
+func NewUpload(ctx context.Context, userID uint, purpose models.UploadPurpose, contentType string, size int64, contentHash string) (models.Upload, string, error) {
+	upload := models.Upload{
+		UserID:      userID,
+		Purpose:     purpose,
+		Status:      models.UploadPending,
+		Key:         fmt.Sprintf("uploads/%d/%s", userID, uuid.NewString()),
+		ContentType: contentType,
+		ByteSize:    size,
+		ContentHash: contentHash,
+		ExpiresAt:   time.Now().Add(uploadPendingTTL),
+	}
+
+	uploadURL, _, err := PresignUploadURL(ctx, upload.Key, contentType, size, UploadURLTTL)
+	if err != nil {
+		return upload, "", err
+	}
+
+	return upload, uploadURL, nil
+}
+
+// ConfirmUpload checks the uploaded file against its type, size and hash and
+// stores it as an image, the same way form uploads are. The raw file is removed
+// either way, a rejected upload can be PUT again while its URL works.
+func ConfirmUpload(ctx context.Context, upload *models.Upload) error {
+	data, err := GetObject(ctx, upload.Key, upload.ByteSize)
+
+	if isMissingObject(err) {
+		return fmt.Errorf("%w: the file hasn't been uploaded yet", ErrUploadRejected)
+	}
+	if errors.Is(err, errObjectTooLarge) {
+		deleteUploadObject(ctx, upload.Key)
+		return fmt.Errorf("%w: the file is larger than announced", ErrUploadRejected)
+	}
+	if err != nil {
+		return err
+	}
+
+	deleteUploadObject(ctx, upload.Key)
+
+	if int64(len(data)) != upload.ByteSize {
+		return fmt.Errorf("%w: the file is %d bytes, %d were announced", ErrUploadRejected, len(data), upload.ByteSize)
+	}
+	if contentType := http.DetectContentType(data); contentType != upload.ContentType {
+		return fmt.Errorf("%w: the file is %s, %s was announced", ErrUploadRejected, contentType, upload.ContentType)
+	}
+	hash := sha256.Sum256(data)
+	if hex.EncodeToString(hash[:]) != upload.ContentHash {
+		return fmt.Errorf("%w: the file doesn't match the announced hash", ErrUploadRejected)
+	}
+
+	processed, err := processImage(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUploadRejected, err)
+	}
+
+	key := fmt.Sprintf("%s/%d/%s.jpg", uploadFolders[upload.Purpose], upload.UserID, uuid.NewString())
+	stored, err := storeImageVariants(ctx, key, processed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	upload.Status = models.UploadConfirmed
+	upload.ConfirmedAt = &now
+	upload.ExpiresAt = now.Add(uploadConfirmedTTL)
+	upload.ImageURL = &stored.URL
+	upload.CardURL = &stored.CardURL
+	upload.ThumbURL = &stored.ThumbURL
+	upload.Width = &stored.Width
+	upload.Height = &stored.Height
+	upload.ImageByteSize = &stored.ByteSize
+	upload.ImageContentHash = &stored.ContentHash
+
+	if err := database.DB.Save(upload).Error; err != nil {
+		DeleteImages(ctx, []string{stored.URL})
+		return fmt.Errorf("failed to confirm upload: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimUploads takes confirmed uploads for use as images, in the order of ids.
+// The uploads are removed, so an image can only be attached once. Run it in the
+// caller's transaction so a failed save leaves them claimable.
+func ClaimUploads(tx *gorm.DB, userID uint, ids []uint, purpose models.UploadPurpose) ([]StoredImage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var uploads []models.Upload
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND user_id = ? AND purpose = ? AND status = ?", ids, userID, purpose, models.UploadConfirmed).
+		Find(&uploads).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch uploads: %w", err)
+	}
+
+	byID := make(map[uint]models.Upload, len(uploads))
+	for _, upload := range uploads {
+		byID[upload.ID] = upload
+	}
+
+	images := make([]StoredImage, 0, len(ids))
+	for _, id := range ids {
+		upload, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: upload %d isn't a confirmed %s upload", ErrUploadRejected, id, purpose)
+		}
+		// a second claim of the same upload would attach the image twice
+		delete(byID, id)
+
+		images = append(images, StoredImage{
+			URL:         *upload.ImageURL,
+			CardURL:     *upload.CardURL,
+			ThumbURL:    *upload.ThumbURL,
+			Width:       *upload.Width,
+			Height:      *upload.Height,
+			ByteSize:    *upload.ImageByteSize,
+			ContentHash: *upload.ImageContentHash,
+		})
+	}
+
+	if err := tx.Where("id IN ?", ids).Delete(&models.Upload{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim uploads: %w", err)
+	}
+
+	return images, nil
+}
+
+// RunUploadCleanup periodically removes uploads that were never confirmed or
+// never attached, along with their files
+func RunUploadCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cleanupExpiredUploads()
+		<-ticker.C
+	}
+}
+
+func cleanupExpiredUploads() {
+	// uploads being claimed are locked and skipped, they're about to go anyway
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var uploads []models.Upload
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("expires_at < ?", time.Now()).
+			Order("id").
+			Limit(uploadCleanupBatch).
+			Find(&uploads).Error; err != nil {
+			return err
+		}
+		if len(uploads) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(uploads))
+		keys := make([]string, len(uploads))
+		var imageURLs []string
+		for i, upload := range uploads {
+			ids[i] = upload.ID
+			keys[i] = upload.Key
+			if upload.ImageURL != nil {
+				imageURLs = append(imageURLs, *upload.ImageURL)
+			}
+		}
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.Upload{}).Error; err != nil {
+			return err
+		}
+		if err := QueueObjectDeletes(tx, keys); err != nil {
+			return err
+		}
+		return QueueImageDeletes(tx, imageURLs)
+	})
+	if err != nil {
+		log.Printf("warning: failed to clean up uploads: %v", err)
+		return
+	}
+
+	WakeStorageOutbox()
+}
+
+func deleteUploadObject(ctx context.Context, key string) {
+	if err := DeleteObject(ctx, key); err != nil {
+		log.Printf("warning: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"gin-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DeleteUserAccount deletes a user with their listings and queues every image
+// and file they leave behind for deletion. Run it in the caller's transaction,
+// a rollback keeps the files and call WakeStorageOutbox once it's committed.
+func DeleteUserAccount(tx *gorm.DB, user models.User) error {
+	// find user listings, including the ones in the trash
+	var listings []models.Listing
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Find(&listings).Error; err != nil {
+		return fmt.Errorf("failed to fetch user listings: %w", err)
+	}
+
+	var imageURLs []string
+	for _, listing := range listings {
+		imageURLs = append(imageURLs, listing.ImageURLs...)
+	}
+
+	// templates have their own copies of images
+	var templates []models.ListingTemplate
+	if err := tx.Where("user_id = ?", user.ID).Find(&templates).Error; err != nil {
+		return fmt.Errorf("failed to fetch user templates: %w", err)
+	}
+
+	for _, template := range templates {
+		imageURLs = append(imageURLs, template.ImageURLs...)
+	}
+
+	// uploads that were never attached to anything
+	var uploads []models.Upload
+	if err := tx.Where("user_id = ?", user.ID).Find(&uploads).Error; err != nil {
+		return fmt.Errorf("failed to fetch user uploads: %w", err)
+	}
+
+	var objectKeys []string
+	for _, upload := range uploads {
+		objectKeys = append(objectKeys, upload.Key)
+		if upload.ImageURL != nil {
+			imageURLs = append(imageURLs, *upload.ImageURL)
+		}
+	}
+
+	// data export archives
+	var exports []models.DataExport
+	if err := tx.Where("user_id = ? AND object_key <> ''", user.ID).Find(&exports).Error; err != nil {
+		return fmt.Errorf("failed to fetch user exports: %w", err)
+	}
+
+	for _, export := range exports {
+		objectKeys = append(objectKeys, export.ObjectKey)
+	}
+
+	// the wishlist goes with the account, the cascade doesn't update the counts
+	if err := tx.Unscoped().Model(&models.Listing{}).
+		Where("id IN (?)", tx.Model(&models.WishlistListing{}).Select("listing_id").Where("user_id = ?", user.ID)).
+		UpdateColumn("wishlist_count", gorm.Expr("GREATEST(wishlist_count - 1, 0)")).Error; err != nil {
+		return fmt.Errorf("failed to update wishlist counts: %w", err)
+	}
+
+	// delete listings for good, the account is gone
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Listing{}).Error; err != nil {
+		return fmt.Errorf("failed to delete user listings: %w", err)
+	}
+
+	imageURLs = append(imageURLs, user.AvatarURL)
+
+	if err := QueueImageDeletes(tx, imageURLs); err != nil {
+		return err
+	}
+	if err := QueueObjectDeletes(tx, objectKeys); err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&user).Error; err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
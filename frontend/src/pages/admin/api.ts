@@ -15,7 +15,7 @@ export type AdminListing = {
   title: string;
   price: number;
   category: string;
-  is_closed: boolean;
+  status: string;
   user_id: number;
   user_email: string;
   user_name: string;
@@ -29,7 +29,7 @@ export async function getAllUsers(): Promise<AdminUser[]> {
       Authorization: token,
     },
   });
-  return data;
+  return data.users;
 }
 
 export async function getAllListings(): Promise<AdminListing[]> {
@@ -39,7 +39,7 @@ export async function getAllListings(): Promise<AdminListing[]> {
       Authorization: token,
     },
   });
-  return data;
+  return data.listings;
 }
 
 export async function deleteUser(userId: number) {
@@ -182,12 +182,12 @@ export default function AdminPanel() {
                       <td>
                         <span
                           className="{
-                            listing.is_closed
+                            listing.status !== "active"
                               ? styles.badge_closed
                               : styles.badge_available
                           }"
                         >
-                          {listing.is_closed
+                          {listing.status !== "active"
                             ? t("admin.panel.listings.status.closed")
                             : t("admin.panel.listings.status.available")}
                         </span>
@@ -1,10 +1,16 @@
 import { api } from "../../shared/core/axios";
-import type { ListingData, Rating } from "../../shared/types";
+import type {
+  DailyStatsPoint,
+  ListingData,
+  ListingImport,
+  ListingStatus,
+  Rating,
+} from "../../shared/types";
 
 export interface DashboardStats {
   total_listings: number;
   active_listings: number;
-  closed_listings: number;
+  status_counts: Record<ListingStatus, number>;
   total_wishlists: number;
   average_price: number;
 }
@@ -12,6 +18,7 @@ export interface DashboardStats {
 export interface DashboardData {
   stats: DashboardStats;
   listings: ListingData[];
+  activity: DailyStatsPoint[];
   ratings: {
     ratings: Rating[];
     average_rating: number;
@@ -23,3 +30,42 @@ export const getDashboardStats = async (): Promise<DashboardData> => {
   const response = await api.get("/user/dashboard");
   return response.data;
 };
+
+export const importListings = async (
+  file: File,
+  images?: File
+): Promise<ListingImport> => {
+  const formData = new FormData();
+  formData.append("file", file);
+  if (images) {
+    formData.append("images", images);
+  }
+  const response = await api.post("/user/listings/import", formData, {
+    headers: {
+      "Content-Type": "multipart/form-data",
+    },
+  });
+  return response.data.import;
+};
+
+export const getListingImport = async (id: number): Promise<ListingImport> => {
+  const response = await api.get(`/user/listings/imports/${id}`);
+  return response.data;
+};
+
+export type ExportFormat = "csv" | "ndjson";
+
+export const downloadExport = async (
+  path:
+    | "/user/listings/export"
+    | "/user/listings/wishlist/export"
+    | "/user/ratings/given/export",
+  format: ExportFormat = "csv",
+  columns?: string[]
+): Promise<Blob> => {
+  const response = await api.get(path, {
+    params: { format, columns: columns?.join(",") },
+    responseType: "blob",
+  });
+  return response.data;
+};
@@ -86,7 +86,7 @@ export default function DashboardPage() {
                 <div className={styles.stat_label}>
                   {t("dashboard.closedListings")}
                 </div>
-                <div className={styles.stat_value}>{stats.closed_listings}</div>
+                <div className={styles.stat_value}>{stats.status_counts.sold}</div>
               </div>
             </Card>
 
@@ -1,7 +1,9 @@
 import { api } from "../../shared/core/axios";
-import type { ListingData } from "../../shared/types";
+import type { ListingsPage } from "../../shared/types";
 
-export async function getListings(): Promise<ListingData[]> {
-  const { data } = await api.get("/public/listings");
+export async function getListings(cursor?: string): Promise<ListingsPage> {
+  const { data } = await api.get("/public/listings", {
+    params: { cursor },
+  });
   return data;
 }
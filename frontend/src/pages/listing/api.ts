@@ -1,5 +1,11 @@
 import { api } from "../../shared/core/axios";
-import type { ListingData, PriceSuggestionResponse } from "../../shared/types";
+import type {
+  ListingAnalytics,
+  ListingData,
+  ListingImage,
+  ListingTemplate,
+  PriceSuggestionResponse,
+} from "../../shared/types";
 
 export async function getListing(id: number): Promise<ListingData> {
   const { data } = await api.get(`/public/listings/${id}`);
@@ -24,11 +30,79 @@ export async function updateListing(request: Request) {
   return data;
 }
 
+export async function renewListing(id: number) {
+  const { data } = await api.post(`/user/listings/${id}/renew`);
+  return data;
+}
+
 export async function deleteListing(id: number) {
   const { data } = await api.delete(`/user/listings/${id}`);
   return data;
 }
 
+export async function getListingImages(id: number): Promise<ListingImage[]> {
+  const { data } = await api.get(`/user/listings/${id}/images`);
+  return data;
+}
+
+export async function updateListingImages(
+  id: number,
+  images: { id: number; alt_text?: string }[],
+  coverId?: number
+): Promise<{ success: boolean; images: ListingImage[] }> {
+  const { data } = await api.put(`/user/listings/${id}/images`, {
+    images,
+    cover_id: coverId,
+  });
+  return data;
+}
+
+export async function cloneListing(id: number, status?: "draft" | "active") {
+  const { data } = await api.post(`/user/listings/${id}/clone`, { status });
+  return data;
+}
+
+export async function saveListingAsTemplate(
+  id: number,
+  name: string
+): Promise<{ template: ListingTemplate }> {
+  const { data } = await api.post(`/user/listings/${id}/template`, { name });
+  return data;
+}
+
+export async function getListingTemplates(): Promise<ListingTemplate[]> {
+  const { data } = await api.get("/user/templates");
+  return data;
+}
+
+export async function createListingFromTemplate(
+  id: number,
+  status?: "draft" | "active"
+) {
+  const { data } = await api.post(`/user/templates/${id}/listings`, { status });
+  return data;
+}
+
+export async function deleteListingTemplate(id: number) {
+  const { data } = await api.delete(`/user/templates/${id}`);
+  return data;
+}
+
+export async function recordContactClick(id: number) {
+  const { data } = await api.post(`/public/listings/${id}/contact`);
+  return data;
+}
+
+export async function getListingAnalytics(
+  id: number,
+  days = 30
+): Promise<ListingAnalytics> {
+  const { data } = await api.get(`/user/listings/${id}/analytics`, {
+    params: { days },
+  });
+  return data;
+}
+
 export async function addToWishlist(id: number) {
   const { data } = await api.post(`/user/listings/wishlist/${id}`);
   return data;
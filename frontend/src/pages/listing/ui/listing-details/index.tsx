@@ -21,7 +21,12 @@ import { useRef, useState } from "react";
 import UpdateListingForm from "../updating-listing";
 import DeletingAlert from "../deleting-alert";
 import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
-import { addToWishlist, createAIPriceReport, updateListing } from "../../api";
+import {
+  addToWishlist,
+  createAIPriceReport,
+  renewListing,
+  updateListing,
+} from "../../api";
 import { checkUserRating } from "../../../../shared/api";
 import styles from "../../styles.module.css";
 import { LightBulbIcon } from "@heroicons/react/24/solid";
@@ -71,6 +76,16 @@ export default function ListingDetails({
     },
   });
 
+  const renewMutation = useMutation({
+    mutationFn: renewListing,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ["listing", listing.id] });
+    },
+    onError: (error: ServerError) => {
+      console.log(error.response.data.error);
+    },
+  });
+
   const likeMutation = useMutation({
     mutationFn: addToWishlist,
     onSuccess: (data) => {
@@ -120,14 +135,28 @@ export default function ListingDetails({
     setIsDeleting(true);
   }
 
+  const isSold = listing.status !== "active";
+
   function handleToggleStatus() {
     if (updateMutation.isPending) return;
     const formData = new FormData();
-    formData.append("is_closed", String(!listing.is_closed));
+    formData.append("status", isSold ? "active" : "sold");
     const id = listing.id;
     updateMutation.mutate({ id, formData });
   }
 
+  const RENEW_WINDOW_MS = 3 * 24 * 60 * 60 * 1000;
+  const canRenew =
+    listing.status === "expired" ||
+    (listing.status === "active" &&
+      !!listing.expires_at &&
+      new Date(listing.expires_at).getTime() - Date.now() < RENEW_WINDOW_MS);
+
+  function handleRenew() {
+    if (renewMutation.isPending) return;
+    renewMutation.mutate(listing.id);
+  }
+
   function handleAddToWishList() {
     if (likeMutation.isPending) return;
     const id = listing.id;
@@ -165,6 +194,16 @@ export default function ListingDetails({
             <ArrowPathIcon className="size-5" />
             <span>{t("listingDetails.buttons.update")}</span>
           </Button>
+          {canRenew && (
+            <Button
+              className="flex-1 flex justify-center gap-1 items-center font-medium"
+              variant="primary"
+              onClick={handleRenew}
+            >
+              <CalendarDaysIcon className="size-5" />
+              <span>{t("listingDetails.buttons.renew")}</span>
+            </Button>
+          )}
           <Button
             className="flex-1 flex justify-center gap-1 items-center font-medium"
             variant="danger"
@@ -202,7 +241,9 @@ export default function ListingDetails({
               <p className="text-4xl text-highlight font-semibold">
                 {listing.price}
               </p>
-              <p className="text-xl text-muted-foreground font-medium">USD</p>
+              <p className="text-xl text-muted-foreground font-medium">
+                {listing.currency ?? "USD"}
+              </p>
             </div>
             {isAuthenticated &&
               authData.user.id !== listingData.listing.user_id && (
@@ -241,7 +282,7 @@ export default function ListingDetails({
               <div className="flex items-center gap-2 ">
                 <span
                   className={`text-sm font-medium ${
-                    listing.is_closed ? "text-muted-foreground" : "text-accent"
+                    isSold ? "text-muted-foreground" : "text-accent"
                   }`}
                 >
                   {t("listingDetails.status.available")}
@@ -250,21 +291,21 @@ export default function ListingDetails({
                 <button
                   onClick={handleToggleStatus}
                   className={
-                    listing.is_closed
+                    isSold
                       ? styles.listing_status_switch_sold
                       : styles.listing_status_switch_available
                   }
                 >
                   <span
                     className={`${styles.listing_switch_pointer} ${
-                      listing.is_closed ? "translate-x-6" : "translate-x-1"
+                      isSold ? "translate-x-6" : "translate-x-1"
                     }`}
                   />
                 </button>
 
                 <span
                   className={`text-sm font-medium ${
-                    listing.is_closed
+                    isSold
                       ? "text-destructive"
                       : "text-muted-foreground"
                   }`}
@@ -276,12 +317,12 @@ export default function ListingDetails({
               <div className="flex justify-between">
                 <div
                   className={
-                    listing.is_closed
+                    isSold
                       ? styles.listing_status_sold
                       : styles.listing_status_available
                   }
                 >
-                  {listing.is_closed
+                  {isSold
                     ? t("listingDetails.status.sold")
                     : t("listingDetails.status.available")}
                 </div>
@@ -7,9 +7,31 @@ export type SearchParams = {
   category?: string;
 };
 
+export type SearchResult = ListingData & {
+  rank?: number;
+  highlight?: {
+    title: string;
+    description: string;
+  };
+};
+
+export type FacetCount = {
+  value: string;
+  count: number;
+};
+
+export type SearchFacets = {
+  categories: FacetCount[];
+  price_buckets: { min: number; max: number | null; count: number }[];
+  universities: FacetCount[];
+  status: FacetCount[];
+};
+
 export type SearchResponse = {
-  listings: ListingData[];
+  listings: SearchResult[];
   total: number;
+  did_you_mean?: string;
+  facets?: SearchFacets;
 };
 
 export async function searchListings(
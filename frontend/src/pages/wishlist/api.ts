@@ -3,5 +3,5 @@ import type { Listing } from "../../shared/types";
 
 export async function getWishlist(): Promise<Listing[]> {
   const { data } = await api.get(`/user/listings/wishlist`);
-  return data;
+  return data.listings;
 }
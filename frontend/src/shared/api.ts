@@ -1,11 +1,17 @@
 import { api } from "./core/axios";
 import type {
   AuthResponse,
+  CampusLocation,
+  CategoryAttribute,
+  CategoryNode,
   CheckRatingResponse,
   CreateRatingDTO,
   Rating,
   RatingResponse,
+  SuggestResponse,
   UpdateRatingDTO,
+  Upload,
+  UploadPurpose,
 } from "./types";
 
 export async function getUserData(): Promise<AuthResponse> {
@@ -13,6 +19,40 @@ export async function getUserData(): Promise<AuthResponse> {
   return data;
 }
 
+export async function getSuggestions(
+  q: string,
+  signal?: AbortSignal
+): Promise<SuggestResponse> {
+  const { data } = await api.get("/public/listings/suggest", {
+    params: { q },
+    signal,
+  });
+  return data;
+}
+
+export async function getCategories(lang?: string): Promise<CategoryNode[]> {
+  const { data } = await api.get("/public/categories", {
+    params: { lang },
+  });
+  return data;
+}
+
+export async function getCategoryAttributes(
+  slug: string
+): Promise<CategoryAttribute[]> {
+  const { data } = await api.get(`/public/categories/${slug}/attributes`);
+  return data;
+}
+
+export async function getCampusLocations(
+  university?: string
+): Promise<CampusLocation[]> {
+  const { data } = await api.get("/public/campus-locations", {
+    params: { university },
+  });
+  return data;
+}
+
 // Rating API functions
 export async function createRating(
   ratingData: CreateRatingDTO
@@ -28,7 +68,7 @@ export async function getUserRatings(userId: number): Promise<RatingResponse> {
 
 export async function getMyRatingsGiven(): Promise<Rating[]> {
   const { data } = await api.get("/user/ratings/given");
-  return data;
+  return data.ratings;
 }
 
 export async function updateRating(
@@ -54,3 +94,36 @@ export async function checkUserRating(
   const { data } = await api.get(`/public/ratings/check/${sellerId}${params}`);
   return data;
 }
+
+// Direct uploads: the file goes straight to storage with a presigned URL and is
+// then confirmed, the returned upload ID is what listings and avatars take
+export async function uploadImageDirect(
+  file: File,
+  purpose: UploadPurpose
+): Promise<Upload> {
+  const digest = await crypto.subtle.digest("SHA-256", await file.arrayBuffer());
+  const sha256 = Array.from(new Uint8Array(digest))
+    .map((b) => b.toString(16).padStart(2, "0"))
+    .join("");
+
+  const { data } = await api.post("/user/uploads", {
+    purpose,
+    content_type: file.type,
+    byte_size: file.size,
+    sha256,
+  });
+
+  const response = await fetch(data.upload_url, {
+    method: data.method,
+    headers: data.headers,
+    body: file,
+  });
+  if (!response.ok) {
+    throw new Error(`Upload failed with status ${response.status}`);
+  }
+
+  const { data: confirmed } = await api.post(
+    `/user/uploads/${data.upload.id}/confirm`
+  );
+  return confirmed.upload;
+}
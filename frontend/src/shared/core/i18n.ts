@@ -114,6 +114,7 @@ const resources = {
           contactTelegram: "Contact via Telegram",
           contactEmail: "Contact via Email",
           rate: "Rate",
+          renew: "Renew",
         },
         description: "Description",
         status: {
@@ -183,7 +184,7 @@ const resources = {
         title: "Dashboard",
         totalListings: "Total Listings",
         activeListings: "Active Listings",
-        closedListings: "Closed Listings",
+        closedListings: "Sold Listings",
         totalWishlists: "Times Wishlisted",
         averagePrice: "Average Price",
       },
@@ -427,6 +428,7 @@ const resources = {
           contactTelegram: "Связаться по Телеграмму",
           contactEmail: "Связаться по почте",
           rate: "Оценить",
+          renew: "Продлить",
         },
         description: "Описание",
         status: {
@@ -497,7 +499,7 @@ const resources = {
         title: "Панель управления",
         totalListings: "Всего объявлений",
         activeListings: "Активные объявления",
-        closedListings: "Закрытые объявления",
+        closedListings: "Проданные объявления",
         totalWishlists: "Добавлено в вишлист",
         averagePrice: "Средняя цена",
       },
@@ -15,7 +15,7 @@ export const filteredListings: ListingData[] = [
         "/images/macbook.webp",
       ],
       price: 1200,
-      is_closed: false,
+      status: "active",
     },
     is_in_wishlist: true,
   },
@@ -30,7 +30,7 @@ export const filteredListings: ListingData[] = [
       description: "i can't believe you are reading this",
       image_urls: ["/images/hoodie.webp"],
       price: 100,
-      is_closed: false,
+      status: "active",
     },
     is_in_wishlist: false,
   },
@@ -44,7 +44,7 @@ export const filteredListings: ListingData[] = [
       description: "i can't believe you are reading this",
       image_urls: ["/images/hoodie.webp"],
       price: 100,
-      is_closed: true,
+      status: "sold",
     },
     is_in_wishlist: false,
   },
@@ -63,7 +63,7 @@ export const filteredListings: ListingData[] = [
         "/images/macbook.webp",
       ],
       price: 1200,
-      is_closed: true,
+      status: "sold",
     },
     is_in_wishlist: true,
   },
@@ -77,7 +77,7 @@ export const filteredListings: ListingData[] = [
       description: "i can't believe you are reading this",
       image_urls: ["/images/hoodie.webp"],
       price: 100,
-      is_closed: false,
+      status: "active",
     },
     is_in_wishlist: false,
   },
@@ -95,7 +95,7 @@ export const filteredListings: ListingData[] = [
         "/images/macbook.webp",
       ],
       price: 1200,
-      is_closed: true,
+      status: "sold",
     },
     is_in_wishlist: true,
   },
@@ -117,7 +117,7 @@ export const listingData: ListingData = {
       "/images/react-logo.webp",
     ],
     price: 1200,
-    is_closed: false,
+    status: "active",
     user: {
       id: 1,
       created_at: "2025-10-24T18:44:36.309077Z",
@@ -63,6 +63,33 @@ export type User = {
   listings?: ListingData[];
 };
 
+export type ListingStatus =
+  | "draft"
+  | "active"
+  | "reserved"
+  | "sold"
+  | "expired"
+  | "archived"
+  | "removed";
+
+export type ListingCondition =
+  | "new"
+  | "like_new"
+  | "good"
+  | "fair"
+  | "for_parts";
+
+export type CampusLocation = {
+  id: number;
+  university: string;
+  name: string;
+  kind: "building" | "dorm" | "other";
+  address: string;
+  latitude: number;
+  longitude: number;
+  active: boolean;
+};
+
 export type Listing = {
   id: number;
   created_at: string;
@@ -71,16 +98,123 @@ export type Listing = {
   title: string;
   description: string;
   category: string;
+  attributes?: Record<string, string | number | boolean>;
+  condition?: ListingCondition | null;
+  pickup_location_id?: number | null;
+  pickup_location?: CampusLocation;
   image_urls: string[];
+  images?: ListingImage[];
   price: number;
-  is_closed: boolean;
+  price_minor?: number;
+  currency?: string;
+  status: ListingStatus;
+  expires_at?: string;
   user?: User;
   ai_price_report?: PriceSuggestionResponse;
 };
 
+export type ListingImage = {
+  id: number;
+  listing_id: number;
+  url: string;
+  card_url?: string | null;
+  thumb_url?: string | null;
+  position: number;
+  is_cover: boolean;
+  alt_text: string;
+  width?: number | null;
+  height?: number | null;
+  byte_size?: number | null;
+  content_hash?: string | null;
+};
+
 export type ListingData = {
   listing: Listing;
   is_in_wishlist: boolean;
+  converted_price?: { amount: number; currency: string };
+  price_history?: PricePoint[];
+};
+
+export type PricePoint = {
+  created_at: string;
+  price: number;
+  price_minor: number;
+  currency: string;
+};
+
+export type DailyStatsPoint = {
+  day: string;
+  views: number;
+  unique_viewers: number;
+  wishlist_adds: number;
+  contact_clicks: number;
+};
+
+export type StatsTotals = {
+  views: number;
+  viewer_days: number;
+  wishlist_adds: number;
+  contact_clicks: number;
+  wishlist_rate: number;
+  contact_rate: number;
+};
+
+export type ListingAnalytics = {
+  listing_id: number;
+  from: string;
+  to: string;
+  totals: StatsTotals;
+  series: DailyStatsPoint[];
+  category_average: StatsTotals;
+};
+
+export type ListingImportStatus =
+  | "pending"
+  | "processing"
+  | "completed"
+  | "failed";
+
+export type ListingImportRow = {
+  id: number;
+  line: number;
+  title: string;
+  listing_id?: number;
+  error?: string;
+};
+
+export type ListingImport = {
+  id: number;
+  created_at: string;
+  updated_at: string;
+  status: ListingImportStatus;
+  total_rows: number;
+  succeeded_rows: number;
+  failed_rows: number;
+  error?: string;
+  completed_at?: string;
+  rows?: ListingImportRow[];
+};
+
+export type ListingTemplate = {
+  id: number;
+  created_at: string;
+  updated_at: string;
+  name: string;
+  title: string;
+  description: string;
+  image_urls: string[] | null;
+  price: number;
+  price_minor: number;
+  currency: string;
+  category: string;
+  attributes: Record<string, unknown>;
+  condition: string | null;
+  pickup_location_id: number | null;
+};
+
+export type ListingsPage = {
+  listings: ListingData[];
+  next_cursor: string | null;
 };
 
 export type PriceSuggestionResponse = {
@@ -128,9 +262,65 @@ export type RatingResponse = {
   ratings: Rating[];
   average_rating: number;
   rating_count: number;
+  next_cursor: string | null;
 };
 
 export type CheckRatingResponse = {
   has_rated: boolean;
   rating: Rating | null;
 };
+
+export type SuggestResponse = {
+  titles: string[];
+  categories: { category: string; count: number }[];
+};
+
+export type CategoryNode = {
+  id: number;
+  parent_id: number | null;
+  slug: string;
+  name: string;
+  names: Record<string, string>;
+  icon: string;
+  sort_order: number;
+  expiry_days: number | null;
+  children: CategoryNode[];
+};
+
+export type CategoryAttributeType =
+  | "text"
+  | "number"
+  | "boolean"
+  | "enum"
+  | "isbn";
+
+export type CategoryAttribute = {
+  id: number;
+  category_id: number;
+  key: string;
+  names: Record<string, string>;
+  type: CategoryAttributeType;
+  required: boolean;
+  enum_values: string[];
+  sort_order: number;
+};
+
+export type UploadPurpose = "listing" | "avatar";
+
+export type Upload = {
+  id: number;
+  created_at: string;
+  updated_at: string;
+  purpose: UploadPurpose;
+  status: "pending" | "confirmed";
+  content_type: string;
+  byte_size: number;
+  content_hash: string;
+  expires_at: string;
+  confirmed_at?: string;
+  image_url?: string;
+  card_url?: string;
+  thumb_url?: string;
+  width?: number;
+  height?: number;
+};
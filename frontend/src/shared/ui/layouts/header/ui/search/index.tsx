@@ -1,7 +1,8 @@
 import { useTranslation } from "react-i18next";
 import type { SetURLSearchParams } from "react-router";
-import { useEffect, useState } from "react";
+import { useEffect, useState, type FormEvent } from "react";
 import { useLocation, useNavigate } from "react-router";
+import { getSuggestions } from "../../../../../api";
 
 type SearchProps = {
   searchParams: URLSearchParams;
@@ -12,6 +13,7 @@ export default function Search({ searchParams, setSearchParams }: SearchProps) {
   const searchQuery = searchParams.get("query") || "";
   const { t } = useTranslation();
   const [inputValue, setInputValue] = useState(searchQuery);
+  const [suggestions, setSuggestions] = useState<string[]>([]);
   const location = useLocation();
   const navigate = useNavigate();
 
@@ -19,39 +21,80 @@ export default function Search({ searchParams, setSearchParams }: SearchProps) {
     setInputValue(searchQuery);
   }, [searchQuery]);
 
+  // fetch lightweight completions while typing, full search runs on submit
   useEffect(() => {
+    const query = inputValue.trim();
+    if (!query || query === searchQuery) {
+      setSuggestions([]);
+      return;
+    }
+
+    const controller = new AbortController();
     const timer = setTimeout(() => {
-      if (inputValue.trim()) {
-        if (location.pathname === "/search") {
-          setSearchParams((prev) => {
-            const newParams = new URLSearchParams(prev);
-            newParams.delete("category");
-            newParams.set("query", inputValue);
-            newParams.set("page", "1");
-            return newParams;
-          });
-        } else {
-          navigate(`/search?query=${encodeURIComponent(inputValue)}&page=1`);
-        }
-      } else if (location.pathname === "/search") {
+      getSuggestions(query, controller.signal)
+        .then((data) => setSuggestions(data.titles))
+        .catch(() => setSuggestions([]));
+    }, 200);
+
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [inputValue, searchQuery]);
+
+  const submit = (value: string) => {
+    setSuggestions([]);
+
+    if (value.trim()) {
+      if (location.pathname === "/search") {
         setSearchParams((prev) => {
           const newParams = new URLSearchParams(prev);
-          newParams.delete("query");
+          newParams.delete("category");
+          newParams.set("query", value);
+          newParams.set("page", "1");
           return newParams;
         });
+      } else {
+        navigate(`/search?query=${encodeURIComponent(value)}&page=1`);
       }
-    }, 500);
+    } else if (location.pathname === "/search") {
+      setSearchParams((prev) => {
+        const newParams = new URLSearchParams(prev);
+        newParams.delete("query");
+        return newParams;
+      });
+    }
+  };
+
+  const handleSubmit = (e: FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+    submit(inputValue);
+  };
+
+  const handleChange = (value: string) => {
+    setInputValue(value);
 
-    return () => clearTimeout(timer);
-  }, [inputValue, location.pathname, navigate, setSearchParams]);
+    // picking an option from the datalist searches right away
+    if (suggestions.includes(value)) {
+      submit(value);
+    }
+  };
 
   return (
-    <input
-      type="text"
-      className="w-full md:w-1/2"
-      placeholder={t("search.placeholder")}
-      value={inputValue}
-      onChange={(e) => setInputValue(e.target.value)}
-    />
+    <form className="w-full md:w-1/2" onSubmit={handleSubmit}>
+      <input
+        type="text"
+        className="w-full"
+        placeholder={t("search.placeholder")}
+        value={inputValue}
+        list="search-suggestions"
+        onChange={(e) => handleChange(e.target.value)}
+      />
+      <datalist id="search-suggestions">
+        {suggestions.map((suggestion) => (
+          <option key={suggestion} value={suggestion} />
+        ))}
+      </datalist>
+    </form>
   );
 }
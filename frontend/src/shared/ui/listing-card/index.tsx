@@ -9,6 +9,7 @@ import type { Listing, ServerError } from "../../types";
 import { addToWishlist } from "../../../pages/listing/api";
 import { Card } from "../card";
 import { timeAgo } from "../../helpers/timeAgo";
+import { formatPrice } from "../../helpers/formatPrice";
 
 export default function ListingCard({
   listing,
@@ -51,22 +52,23 @@ export default function ListingCard({
         <div className={styles.card_img}>
           <img
             src={
-              listing.image_urls && listing.image_urls.length > 0
+              listing.images?.[0]?.card_url ||
+              (listing.image_urls && listing.image_urls.length > 0
                 ? listing.image_urls[0]
-                : "/images/placeholder.webp"
+                : "/images/placeholder.webp")
             }
             className="w-full h-full object-cover"
-            alt={`${listing.title} image 1`}
+            alt={listing.images?.[0]?.alt_text || `${listing.title} image 1`}
           />
 
           <span
             className={
-              listing.is_closed
+              listing.status !== "active"
                 ? styles.card_status_sold
                 : styles.card_status_available
             }
           >
-            {listing.is_closed
+            {listing.status !== "active"
               ? t("listingCard.status.sold")
               : t("listingCard.status.available")}
           </span>
@@ -93,7 +95,9 @@ export default function ListingCard({
           </div>
 
           <div className="w-full flex justify-between mt-auto">
-            <h2 className={styles.card_price}>${listing.price}</h2>
+            <h2 className={styles.card_price}>
+              {formatPrice(listing.price, listing.currency)}
+            </h2>
 
             {isAuthenticated && authData.user.id !== listing.user_id && (
               <button